@@ -1,6 +1,8 @@
 package main
 
 import (
+	"time"
+
 	"github.com/gopherjs/vecty"
 	"github.com/gopherjs/vecty/elem"
 	gpscomponent "github.com/shurcooL/Go-Package-Store/component"
@@ -21,10 +23,16 @@ func (*UpdatesBody) Render() vecty.ComponentOrHTML {
 			mockActive,
 			mockHistory,
 			true,
+			mockRateLimit,
 		)...,
 	)
 }
 
+var mockRateLimit = &model.RateLimit{
+	Service: "GitHub API",
+	Reset:   time.Now().Add(12 * time.Minute),
+}
+
 var mockActive = []*model.RepoPresentation{
 	{
 		RepoRoot:          "github.com/gopherjs/gopherjs",
@@ -33,9 +41,60 @@ var mockActive = []*model.RepoPresentation{
 		RemoteRevision:    "",
 		HomeURL:           "https://github.com/gopherjs/gopherjs",
 		ImageURL:          "https://avatars.githubusercontent.com/u/6654647?v=3",
+		Releases: []model.Release{
+			{
+				Tag:  "1.8-1",
+				Name: "GopherJS 1.8-1",
+				Date: time.Date(2017, time.February, 26, 0, 0, 0, 0, time.UTC),
+				Body: "<p>This is the first release of GopherJS that supports Go 1.8.</p>\n<ul>\n<li>Updated to Go 1.8.</li>\n<li>Improved reflect support for blocking functions.</li>\n</ul>",
+				URL:  "https://github.com/gopherjs/gopherjs/releases/tag/1.8-1",
+			},
+		},
+		Advisories: []model.Advisory{
+			{
+				ID:      "GO-2017-0001",
+				Aliases: []string{"CVE-2017-0001"},
+				Summary: "Cross-site scripting in js.Global access",
+				URL:     "https://pkg.go.dev/vuln/GO-2017-0001",
+			},
+		},
+		Diffstat: &model.Diffstat{
+			Additions: 124,
+			Deletions: 37,
+			Packages: []model.PackageDiffstat{
+				{
+					ImportPath: "github.com/gopherjs/gopherjs/compiler",
+					Additions:  100,
+					Deletions:  30,
+					Files: []model.FileChange{
+						{Name: "expressions.go", Additions: 80, Deletions: 10},
+						{Name: "statements.go", Additions: 20, Deletions: 20},
+					},
+				},
+				{
+					ImportPath: "github.com/gopherjs/gopherjs/js",
+					Additions:  24,
+					Deletions:  7,
+					Files: []model.FileChange{
+						{Name: "js.go", Additions: 24, Deletions: 7},
+					},
+				},
+			},
+		},
+		ImportedBy: []string{
+			"github.com/shurcooL/Go-Package-Store/cmd/Go-Package-Store",
+			"github.com/shurcooL/Go-Package-Store/frontend",
+		},
+		IncompatibleChanges: []string{
+			"github.com/gopherjs/gopherjs/js.Object.Interface: removed",
+			"github.com/gopherjs/gopherjs/js.MakeFunc: changed from func(func(this *Object, arguments []*Object) interface{}) *Object to func(func(this *Object, arguments []*Object) any) *Object",
+		},
 		Changes: []model.Change{
 			{
 				Message: "improved reflect support for blocking functions",
+				Body:    "Functions called via reflect.Value.Call can now block.\n\nFixes #600.",
+				Author:  model.Author{Name: "Richard Musiol", AvatarURL: "https://avatars.githubusercontent.com/u/86036?v=3"},
+				Time:    time.Date(2017, time.February, 25, 15, 4, 0, 0, time.UTC),
 				URL:     "https://github.com/gopherjs/gopherjs/commit/87bf7e405aa3df6df0dcbb9385713f997408d7b9",
 				Comments: model.Comments{
 					Count: 0,
@@ -62,6 +121,7 @@ var mockActive = []*model.RepoPresentation{
 		Error:           "",
 		UpdateState:     model.Available,
 		UpdateSupported: true,
+		IgnoreSupported: true,
 	},
 	{
 		RepoRoot:          "golang.org/x/image",
@@ -92,9 +152,71 @@ var mockActive = []*model.RepoPresentation{
 		HomeURL:           "https://unknown.com/package",
 		ImageURL:          "https://github.com/images/gravatars/gravatar-user-420.png",
 		Changes:           nil,
+		LicenseChange:     &model.LicenseChange{From: "MIT", To: "AGPL-3.0"},
 		Error:             "",
 		UpdateState:       model.Available,
 		UpdateSupported:   true,
+		UpdateRefused:     "license changed from MIT to AGPL-3.0",
+	},
+	{
+		RepoRoot:          "example.com/hacking",
+		ImportPathPattern: "example.com/hacking/...",
+		LocalRevision:     "0123456789abcdef000000000000000000000000",
+		RemoteRevision:    "fedcba9876543210000000000000000000000000",
+		HomeURL:           "https://example.com/hacking",
+		ImageURL:          "https://github.com/images/gravatars/gravatar-user-420.png",
+		DirtyStatus:       " M main.go\n?? notes.txt\n",
+		UpdateState:       model.Available,
+		UpdateSupported:   true,
+		UpdateRefused:     "working tree is dirty, use -stash flag to stash local changes while updating",
+	},
+	{
+		RepoRoot:          "github.com/canonical/lib",
+		ImportPathPattern: "github.com/canonical/lib/...",
+		LocalRevision:     "4444444444444444444444444444444444444444",
+		RemoteRevision:    "5555555555555555555555555555555555555555",
+		HomeURL:           "https://github.com/canonical/lib",
+		ImageURL:          "https://github.com/images/gravatars/gravatar-user-420.png",
+		Fork: &model.Fork{
+			ForkURL:          "https://github.com/example/lib",
+			UpstreamURL:      "https://github.com/canonical/lib",
+			UpstreamBranch:   "master",
+			UpstreamRevision: "6666666666666666666666666666666666666666",
+			Behind:           12,
+		},
+		UpdateState:     model.Available,
+		UpdateSupported: true,
+	},
+	{
+		RepoRoot:          "github.com/example/fork",
+		ImportPathPattern: "github.com/example/fork/...",
+		LocalRevision:     "1111111111111111111111111111111111111111",
+		RemoteRevision:    "2222222222222222222222222222222222222222",
+		TrackedBranch:     "release-1.x",
+		HomeURL:           "https://github.com/example/fork",
+		ImageURL:          "https://github.com/images/gravatars/gravatar-user-420.png",
+		Diverged: &model.Divergence{
+			MergeBase: "3333333333333333333333333333333333333333",
+			LocalCommits: []model.Commit{
+				{Revision: "1111111111111111111111111111111111111111", Message: "Add local workaround for flaky test."},
+			},
+		},
+		UpdateState:     model.Available,
+		UpdateSupported: true,
+		RevertSupported: true,
+	},
+	{
+		RepoRoot:          "example.org/offline",
+		ImportPathPattern: "example.org/offline/...",
+		LocalRevision:     "7777777777777777777777777777777777777777",
+		RemoteRevision:    "8888888888888888888888888888888888888888",
+		HomeURL:           "https://example.org/offline",
+		ImageURL:          "https://github.com/images/gravatars/gravatar-user-420.png",
+		Stale:             time.Date(2017, 3, 14, 9, 26, 0, 0, time.UTC),
+		Error:             "changes are not known, because the remote can't be reached",
+		UpdateState:       model.Available,
+		UpdateSupported:   true,
+		UpdateRefused:     "offline mode is enabled, updating requires the remote",
 	},
 }
 
@@ -116,6 +238,9 @@ var mockHistory = []*model.RepoPresentation{
 				},
 			},
 		},
+		Verification: &model.Verification{
+			Command: "go build github.com/shurcooL/Go-Package-Store/cmd/Go-Package-Store",
+		},
 		Error:           "",
 		UpdateState:     model.Updated,
 		UpdateSupported: true,
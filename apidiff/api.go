@@ -0,0 +1,207 @@
+package apidiff
+
+import (
+	"bytes"
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+)
+
+// api is the exported API of a single package.
+// Map key is identifier name, value is its declaration.
+type api map[string]string
+
+// loadAPI type-checks all importable packages in src, and returns their exported API.
+// Map key is package import path. root is the import path corresponding to the root of the repository.
+func loadAPI(root string, src source) map[string]api {
+	l := &loader{
+		root:    root,
+		src:     src,
+		fset:    token.NewFileSet(),
+		std:     importer.Default(),
+		checked: make(map[string]*types.Package),
+	}
+	l.ctxt = build.Default
+	l.ctxt.CgoEnabled = false
+	l.ctxt.JoinPath = path.Join
+	l.ctxt.OpenFile = func(name string) (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(src[name])), nil
+	}
+
+	dirs := make(map[string][]string) // Directory -> file names.
+	for name := range src {
+		dirs[path.Dir(name)] = append(dirs[path.Dir(name)], path.Base(name))
+	}
+	l.dirs = dirs
+
+	apis := make(map[string]api)
+	for dir := range dirs {
+		importPath := l.importPath(dir)
+		if isInternal(importPath) {
+			// Internal packages can't be imported by users.
+			continue
+		}
+		pkg, err := l.Import(importPath)
+		if err != nil || pkg.Name() == "" || pkg.Name() == "main" {
+			// Not an importable Go package.
+			continue
+		}
+		apis[importPath] = exportedAPI(pkg)
+	}
+	return apis
+}
+
+// loader type-checks packages of a repository.
+// It implements types.Importer.
+type loader struct {
+	root string
+	src  source
+	dirs map[string][]string // Directory -> file names.
+	ctxt build.Context
+	fset *token.FileSet
+	std  types.Importer // Importer for standard library packages.
+
+	checked map[string]*types.Package // Import path -> package. Nil value means checking is in progress.
+}
+
+func (l *loader) importPath(dir string) string {
+	if dir == "." {
+		return l.root
+	}
+	return l.root + "/" + dir
+}
+
+// Import imports the package with specified import path.
+// Packages in the repository are type-checked from source,
+// standard library packages are imported via l.std, and
+// other packages are substituted with empty packages.
+func (l *loader) Import(importPath string) (*types.Package, error) {
+	if pkg, ok := l.checked[importPath]; ok && pkg != nil {
+		return pkg, nil
+	} else if ok {
+		// Import cycle. Substitute an empty package to avoid infinite recursion.
+		return emptyPackage(importPath), nil
+	}
+
+	var pkg *types.Package
+	switch dir := strings.TrimPrefix(strings.TrimPrefix(importPath, l.root), "/"); {
+	case importPath == l.root || strings.HasPrefix(importPath, l.root+"/"):
+		if dir == "" {
+			dir = "."
+		}
+		l.checked[importPath] = nil
+		pkg = l.check(importPath, dir)
+	case isStandard(importPath):
+		var err error
+		pkg, err = l.std.Import(importPath)
+		if err != nil {
+			pkg = emptyPackage(importPath)
+		}
+	default:
+		pkg = emptyPackage(importPath)
+	}
+	l.checked[importPath] = pkg
+	return pkg, nil
+}
+
+// check type-checks package with specified import path in directory dir of the repository.
+// Type errors are ignored, since dependencies outside the repository aren't available.
+func (l *loader) check(importPath, dir string) *types.Package {
+	var files []*ast.File
+	names := l.dirs[dir]
+	sort.Strings(names)
+	for _, name := range names {
+		if ok, err := l.ctxt.MatchFile(dir, name); err != nil || !ok {
+			continue
+		}
+		file, err := parser.ParseFile(l.fset, path.Join(dir, name), l.src[path.Join(dir, name)], 0)
+		if err != nil {
+			continue
+		}
+		if len(files) > 0 && file.Name.Name != files[0].Name.Name {
+			// Only use files of the first package name found.
+			continue
+		}
+		files = append(files, file)
+	}
+	conf := types.Config{
+		Importer: l,
+		Error:    func(error) {},
+	}
+	pkg, _ := conf.Check(importPath, l.fset, files, nil)
+	return pkg
+}
+
+// exportedAPI returns exported API of pkg.
+func exportedAPI(pkg *types.Package) api {
+	a := make(api)
+	qualifier := types.RelativeTo(pkg)
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		obj := scope.Lookup(name)
+		if !obj.Exported() {
+			continue
+		}
+		switch obj := obj.(type) {
+		case *types.Const:
+			a[name] = "const " + types.TypeString(obj.Type(), qualifier)
+		case *types.Var:
+			a[name] = "var " + types.TypeString(obj.Type(), qualifier)
+		case *types.Func:
+			a[name] = types.TypeString(obj.Type(), qualifier)
+		case *types.TypeName:
+			if obj.IsAlias() {
+				a[name] = "type = " + types.TypeString(obj.Type(), qualifier)
+				continue
+			}
+			switch u := obj.Type().Underlying().(type) {
+			case *types.Struct:
+				// Adding fields to a struct is compatible, so record each field separately.
+				a[name] = "struct"
+				for i := 0; i < u.NumFields(); i++ {
+					if f := u.Field(i); f.Exported() {
+						a[name+"."+f.Name()] = "field " + types.TypeString(f.Type(), qualifier)
+					}
+				}
+			case *types.Interface:
+				// Any change to an interface may break its implementations.
+				a[name] = types.TypeString(u, qualifier)
+				continue
+			default:
+				a[name] = "type " + types.TypeString(u, qualifier)
+			}
+			ms := types.NewMethodSet(types.NewPointer(obj.Type()))
+			for i := 0; i < ms.Len(); i++ {
+				if m := ms.At(i).Obj(); m.Exported() {
+					a[name+"."+m.Name()] = types.TypeString(m.Type(), qualifier)
+				}
+			}
+		}
+	}
+	return a
+}
+
+func emptyPackage(importPath string) *types.Package {
+	pkg := types.NewPackage(importPath, path.Base(importPath))
+	pkg.MarkComplete()
+	return pkg
+}
+
+// isStandard reports whether importPath is a standard library package,
+// i.e., the first element of its import path doesn't contain a dot.
+func isStandard(importPath string) bool {
+	return !strings.Contains(strings.SplitN(importPath, "/", 2)[0], ".")
+}
+
+// isInternal reports whether importPath is an internal package.
+func isInternal(importPath string) bool {
+	return strings.HasSuffix(importPath, "/internal") || strings.Contains(importPath, "/internal/")
+}
@@ -0,0 +1,73 @@
+// Package apidiff reports incompatible changes to the exported API
+// of Go packages between two revisions of a git repository.
+//
+// Packages are type-checked without their dependencies outside of the repository
+// (other than the standard library), so changes that involve only such
+// dependencies may go unreported.
+package apidiff
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Change is an incompatible change to an exported identifier.
+type Change struct {
+	Package string // Import path of the package, e.g., "github.com/owner/repo/pkg".
+	Name    string // Name of the identifier, e.g., "Func", "Type" or "Type.Method". Empty for changes to entire package.
+	Message string // Description of the change, e.g., "removed".
+}
+
+func (c Change) String() string {
+	if c.Name == "" {
+		return c.Package + ": " + c.Message
+	}
+	return c.Package + "." + c.Name + ": " + c.Message
+}
+
+// Incompatible returns incompatible changes to the exported API of Go packages
+// in git repository at dir, between revisions old and new, sorted by package and name.
+// root is the import path corresponding to the root of the repository.
+// Both revisions must be available in the local repository.
+func Incompatible(dir, root, old, new string) ([]Change, error) {
+	oldSrc, err := readRevision(dir, old)
+	if err != nil {
+		return nil, fmt.Errorf("reading revision %v: %v", old, err)
+	}
+	newSrc, err := readRevision(dir, new)
+	if err != nil {
+		return nil, fmt.Errorf("reading revision %v: %v", new, err)
+	}
+	return incompatible(root, oldSrc, newSrc), nil
+}
+
+// incompatible returns incompatible changes to the exported API of Go packages
+// between old and new sources of repository with specified root.
+func incompatible(root string, old, new source) []Change {
+	oldAPI, newAPI := loadAPI(root, old), loadAPI(root, new)
+
+	var cs []Change
+	for pkg, oldIdents := range oldAPI {
+		newIdents, ok := newAPI[pkg]
+		if !ok {
+			cs = append(cs, Change{Package: pkg, Message: "package removed"})
+			continue
+		}
+		for name, oldDecl := range oldIdents {
+			newDecl, ok := newIdents[name]
+			switch {
+			case !ok:
+				cs = append(cs, Change{Package: pkg, Name: name, Message: "removed"})
+			case newDecl != oldDecl:
+				cs = append(cs, Change{Package: pkg, Name: name, Message: fmt.Sprintf("changed from %s to %s", oldDecl, newDecl)})
+			}
+		}
+	}
+	sort.Slice(cs, func(i, j int) bool {
+		if cs[i].Package != cs[j].Package {
+			return cs[i].Package < cs[j].Package
+		}
+		return cs[i].Name < cs[j].Name
+	})
+	return cs
+}
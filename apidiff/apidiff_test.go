@@ -0,0 +1,146 @@
+package apidiff
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestIncompatible(t *testing.T) {
+	old := source{
+		"a.go": []byte(`package foo
+
+import "io"
+
+const Answer = 42
+
+var Default = New()
+
+type Foo struct {
+	Name string
+	r    io.Reader
+}
+
+func New() *Foo { return nil }
+
+func (f *Foo) Read(p []byte) (int, error) { return 0, nil }
+func (f *Foo) Close() error             { return nil }
+
+type Getter interface {
+	Get() string
+}
+
+func unexported() {}
+`),
+		"bar/bar.go": []byte(`package bar
+
+func Bar() {}
+`),
+		"internal/baz/baz.go": []byte(`package baz
+
+func Baz() {}
+`),
+		"cmd/foo/main.go": []byte(`package main
+
+func Main() {}
+`),
+	}
+	new := source{
+		"a.go": []byte(`package foo
+
+import "io"
+
+const Answer = 43
+
+var Default = New(nil)
+
+type Foo struct {
+	Name  string
+	Extra int // Adding a field is compatible.
+	r     io.Writer
+}
+
+func New(r io.Reader) *Foo { return nil }
+
+func (f *Foo) Read(p []byte) (int, error) { return 0, nil }
+
+type Getter interface {
+	Get() string
+	Set(string)
+}
+
+func Added() {}
+`),
+		"internal/baz/baz.go": []byte(`package baz
+`),
+	}
+
+	got := incompatible("example.com/foo", old, new)
+	want := []Change{
+		{Package: "example.com/foo", Name: "Foo.Close", Message: "removed"},
+		{Package: "example.com/foo", Name: "Getter", Message: "changed from interface{Get() string} to interface{Get() string; Set(string)}"},
+		{Package: "example.com/foo", Name: "New", Message: "changed from func() *Foo to func(r io.Reader) *Foo"},
+		{Package: "example.com/foo/bar", Message: "package removed"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got:\n%v\nwant:\n%v", got, want)
+	}
+}
+
+func TestReadRevision(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git binary is required, but not available:", err)
+	}
+	dir, err := ioutil.TempDir("", "apidiff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"foo.go":              "package foo\n",
+		"foo_test.go":         "package foo\n",
+		"bar/bar.go":          "package bar\n",
+		"testdata/x.go":       "package x\n",
+		"vendor/a.com/b/b.go": "package b\n",
+		"_example/example.go": "package example\n",
+		"README.md":           "Foo\n",
+	}
+	for name, content := range files {
+		name = filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "."},
+		{"-c", "user.name=Gopher", "-c", "user.email=gopher@example.com", "commit", "-q", "-m", "Initial commit."},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	want := source{
+		"foo.go":     []byte("package foo\n"),
+		"bar/bar.go": []byte("package bar\n"),
+	}
+	for _, d := range []string{dir, filepath.Join(dir, "bar")} {
+		got, err := readRevision(d, "HEAD")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("readRevision(%q): got %q, want %q", d, got, want)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package apidiff
+
+import (
+	"context"
+	"log"
+	"os/exec"
+
+	"github.com/shurcooL/Go-Package-Store/presenter"
+)
+
+// NewEnricher returns an enricher that fills in Presentation.IncompatibleChanges
+// from the local git repository. Both local and remote revisions must already
+// be available in the local repository, otherwise the repo is skipped;
+// remote commits are never fetched.
+func NewEnricher() presenter.Enricher {
+	return func(ctx context.Context, repo presenter.Repo, p *presenter.Presentation) {
+		if repo.Path == "" || !hasCommit(ctx, repo.Path, repo.LocalRevision) || !hasCommit(ctx, repo.Path, repo.RemoteRevision) {
+			// Not a git repository, or revisions are not available locally.
+			return
+		}
+		// This part might take a while.
+		cs, err := Incompatible(repo.Path, repo.Root, repo.LocalRevision, repo.RemoteRevision)
+		if err != nil {
+			log.Printf("api diff: %v: %v\n", repo.Root, err)
+			return
+		}
+		for _, c := range cs {
+			p.IncompatibleChanges = append(p.IncompatibleChanges, c.String())
+		}
+	}
+}
+
+// hasCommit reports whether git repository at dir has commit rev.
+func hasCommit(ctx context.Context, dir, rev string) bool {
+	cmd := exec.CommandContext(ctx, "git", "cat-file", "-e", rev+"^{commit}")
+	cmd.Dir = dir
+	return cmd.Run() == nil
+}
@@ -0,0 +1,83 @@
+package apidiff
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os/exec"
+	"path"
+	"strings"
+)
+
+// source is the Go source code of a repository at some revision.
+// Map key is the slash-separated path of a .go file, relative to repository root.
+type source map[string][]byte
+
+// readRevision reads Go source code of git repository at dir at revision rev.
+// dir may be any directory inside the repository.
+// Test files and files in directories that the go tool ignores are skipped.
+func readRevision(dir, rev string) (source, error) {
+	// Archive the entire repository, rather than just the subdirectory dir.
+	topLevel, err := exec.Command("git", "-C", dir, "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return nil, fmt.Errorf("determining top-level directory of repository: %v", err)
+	}
+
+	cmd := exec.Command("git", "archive", "--format=tar", rev)
+	cmd.Dir = strings.TrimSuffix(string(topLevel), "\n")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	err = cmd.Start()
+	if err != nil {
+		return nil, err
+	}
+	src := make(source)
+	tr := tar.NewReader(stdout)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			cmd.Wait()
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg || !isGoSource(hdr.Name) {
+			continue
+		}
+		b, err := ioutil.ReadAll(tr)
+		if err != nil {
+			cmd.Wait()
+			return nil, err
+		}
+		src[hdr.Name] = b
+	}
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("%v: %v", err, strings.TrimSpace(stderr.String()))
+	}
+	return src, nil
+}
+
+// isGoSource reports whether file at slash-separated path name
+// is a non-test Go source file that the go tool doesn't ignore.
+func isGoSource(name string) bool {
+	if !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+		return false
+	}
+	if base := path.Base(name); strings.HasPrefix(base, ".") || strings.HasPrefix(base, "_") {
+		return false
+	}
+	for _, elem := range strings.Split(path.Dir(name), "/") {
+		if strings.HasPrefix(elem, ".") && elem != "." ||
+			strings.HasPrefix(elem, "_") ||
+			elem == "testdata" || elem == "vendor" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/shurcooL/Go-Package-Store"
+	"github.com/shurcooL/Go-Package-Store/frontend/model"
+	"github.com/shurcooL/Go-Package-Store/workspace"
+	"github.com/shurcooL/httperror"
+)
+
+// BatchHandler handles requests to update repos with RepoRoot values as a single batch.
+// If any update or its verification fails, the whole batch is rolled back.
+// It's only available if the updater is a gps.Reverter.
+func (u updateWorker) BatchHandler(w http.ResponseWriter, req *http.Request) error {
+	if req.Method != "POST" {
+		return httperror.Method{Allowed: []string{"POST"}}
+	}
+	if err := req.ParseForm(); err != nil {
+		return httperror.BadRequest{Err: err}
+	}
+	roots := req.PostForm["RepoRoot"]
+	if len(roots) == 0 {
+		return httperror.BadRequest{Err: fmt.Errorf("missing RepoRoot")}
+	}
+
+	ur := updateRequest{
+		Batch:        roots,
+		ResponseChan: make(chan error),
+	}
+	u.updateRequests <- ur
+
+	var result model.BatchResult
+	switch err := (<-ur.ResponseChan).(type) {
+	case nil:
+	case batchError:
+		log.Println("batch update error:", err)
+		result.Error = err.Err.Error()
+		result.RolledBack = err.RolledBack
+	default:
+		log.Println("batch update error:", err)
+		result.Error = err.Error()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	return json.NewEncoder(w).Encode(result)
+}
+
+// batchError is an error that a batch update failed with.
+type batchError struct {
+	Err        error
+	RolledBack bool // All updates in the batch were rolled back.
+}
+
+func (e batchError) Error() string {
+	if e.RolledBack {
+		return e.Err.Error() + " (batch was rolled back)"
+	}
+	return e.Err.Error()
+}
+
+// updateBatch updates repos with specified roots, ordered so that repos are updated
+// after repos they import. If any update or its verification fails, all updates
+// in the batch are rolled back, and a batchError is returned.
+func (u updateWorker) updateBatch(roots []string) error {
+	reverter, ok := u.updater.(gps.Reverter)
+	if !ok {
+		return fmt.Errorf("batch updates are not supported by updater %T, because it can't revert", u.updater)
+	}
+
+	rps := make(map[string]*workspace.RepoPresentation)
+	importedBy := make(map[string][]string)
+	c.pipeline.Packages.Lock()
+	for _, root := range roots {
+		rp, ok := c.pipeline.Packages.ByRoot[root]
+		if !ok {
+			c.pipeline.Packages.Unlock()
+			return fmt.Errorf("root %q not found", root)
+		}
+		if rp.UpdateState != workspace.Available {
+			c.pipeline.Packages.Unlock()
+			return fmt.Errorf("root %q not available for update: %v", root, rp.UpdateState)
+		}
+		if reason := updateRefusal(rp); reason != "" {
+			c.pipeline.Packages.Unlock()
+			return fmt.Errorf("refusing to update root %q: %v", root, reason)
+		}
+		rps[root] = rp
+		importedBy[root] = rp.Analysis.ImportedBy
+	}
+	// Mark repos as updating.
+	for _, rp := range rps {
+		rp.UpdateState = workspace.Updating
+	}
+	c.pipeline.Packages.Unlock()
+
+	var (
+		applied []*workspace.RepoPresentation
+		failure error
+	)
+	for _, root := range orderBatch(roots, importedBy) {
+		rp := rps[root]
+		var output bytes.Buffer
+		err := u.update(rp.Repo, io.MultiWriter(os.Stdout, &output))
+		// A failed update may have changed the repo partially, so it's rolled back too.
+		applied = append(applied, rp)
+		var verification *workspace.Verification
+		if err == nil {
+			verification = u.verify(rp, false)
+		}
+		logUpdate(u.updater, root, rp.Repo.Local.Revision, rp.Repo.Remote.Revision, err, output.String(), verification)
+		c.pipeline.Packages.Lock()
+		rp.Verification = verification
+		c.pipeline.Packages.Unlock()
+
+		if err != nil {
+			failure = fmt.Errorf("updating %v failed: %v", root, err)
+			break
+		}
+		if verification != nil && verification.Error != "" {
+			failure = fmt.Errorf("verifying %v failed: %v", root, verification.Error)
+			break
+		}
+	}
+	if failure == nil {
+		for _, rp := range applied {
+			markUpdated(rp)
+		}
+		fmt.Println("\nDone.")
+		return nil
+	}
+
+	// Roll back the whole batch, in reverse order.
+	fmt.Println("batch update failed, rolling back")
+	rolledBack := true
+	for i := len(applied) - 1; i >= 0; i-- {
+		rp := applied[i]
+		var output bytes.Buffer
+		err := u.revertTo(reverter, rp.Repo, rp.Repo.Local.Revision, io.MultiWriter(os.Stdout, &output))
+		logUpdate(u.updater, rp.Repo.Root, rp.Repo.Remote.Revision, rp.Repo.Local.Revision, err, output.String(), nil)
+		if err != nil {
+			rolledBack = false
+			failure = fmt.Errorf("%v; rolling back %v failed: %v", failure, rp.Repo.Root, err)
+		}
+	}
+	// Mark repos as available for update again.
+	c.pipeline.Packages.Lock()
+	for _, rp := range rps {
+		rp.UpdateState = workspace.Available
+	}
+	c.pipeline.Packages.Unlock()
+	fmt.Println("\nDone.")
+	return batchError{Err: failure, RolledBack: rolledBack}
+}
+
+// orderBatch orders repos with specified roots so that each repo comes after
+// the repos in the batch that it imports. importedBy maps repo root to import paths
+// of Go packages that import it. Repos that don't depend on each other keep
+// their relative order, and import cycles are broken in favor of that order.
+func orderBatch(roots []string, importedBy map[string][]string) []string {
+	// dependsOn reports whether repo with root a imports repo with root b.
+	dependsOn := func(a, b string) bool {
+		for _, importPath := range importedBy[b] {
+			if importPath == a || strings.HasPrefix(importPath, a+"/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	var (
+		ordered   []string
+		done      = make(map[string]bool)
+		remaining = append([]string(nil), roots...)
+	)
+	for len(remaining) > 0 {
+		next := 0 // Index in remaining of the next repo, the first one if there's a cycle.
+	Remaining:
+		for i, a := range remaining {
+			for _, b := range remaining {
+				if a != b && !done[b] && dependsOn(a, b) {
+					continue Remaining
+				}
+			}
+			next = i
+			break
+		}
+		ordered = append(ordered, remaining[next])
+		done[remaining[next]] = true
+		remaining = append(remaining[:next], remaining[next+1:]...)
+	}
+	return ordered
+}
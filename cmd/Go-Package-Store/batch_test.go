@@ -0,0 +1,52 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOrderBatch(t *testing.T) {
+	tests := []struct {
+		roots      []string
+		importedBy map[string][]string
+		want       []string
+	}{
+		{
+			roots: []string{"example.com/a", "example.com/b", "example.com/c"},
+			want:  []string{"example.com/a", "example.com/b", "example.com/c"},
+		},
+		{
+			// a imports b, which imports c.
+			roots: []string{"example.com/a", "example.com/b", "example.com/c"},
+			importedBy: map[string][]string{
+				"example.com/b": {"example.com/a/sub"},
+				"example.com/c": {"example.com/b", "example.com/other"},
+			},
+			want: []string{"example.com/c", "example.com/b", "example.com/a"},
+		},
+		{
+			// Import path example.com/ab is not in repo example.com/a.
+			roots: []string{"example.com/a", "example.com/b"},
+			importedBy: map[string][]string{
+				"example.com/b": {"example.com/ab"},
+			},
+			want: []string{"example.com/a", "example.com/b"},
+		},
+		{
+			// a and b import each other.
+			roots: []string{"example.com/a", "example.com/b", "example.com/c"},
+			importedBy: map[string][]string{
+				"example.com/a": {"example.com/b"},
+				"example.com/b": {"example.com/a"},
+				"example.com/c": {"example.com/a"},
+			},
+			want: []string{"example.com/c", "example.com/a", "example.com/b"},
+		},
+	}
+	for _, tc := range tests {
+		got := orderBatch(tc.roots, tc.importedBy)
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("orderBatch(%q): got %q, want %q", tc.roots, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/shurcooL/Go-Package-Store"
+	"github.com/shurcooL/Go-Package-Store/config"
+	"github.com/shurcooL/Go-Package-Store/urlrewrite"
+	"github.com/shurcooL/httperror"
+)
+
+// openConfig opens the user configuration file at path,
+// or in the user configuration directory if path is empty.
+// It returns nil if the configuration is not available.
+func openConfig(path string) *config.File {
+	if path == "" {
+		var err error
+		path, err = config.Path()
+		if err != nil {
+			log.Println("skipping ignore list and pins, because unable to acquire a config dir:", err)
+			return nil
+		}
+	}
+	f, err := config.Open(path)
+	if err != nil {
+		log.Println("skipping ignore list and pins, because unable to open config file:", err)
+		return nil
+	}
+	return f
+}
+
+// urlRewriteRules returns rules for rewriting remote URLs to their canonical form,
+// made of mirrors in the user configuration and git's url.<base>.insteadOf configuration.
+func urlRewriteRules() urlrewrite.Rules {
+	var rs urlrewrite.Rules
+	if c.config != nil {
+		for _, m := range c.config.Mirrors() {
+			rs = append(rs, urlrewrite.Rule{Prefix: m.URL, Replacement: m.Canonical})
+		}
+	}
+	insteadOf, err := urlrewrite.GitInsteadOf()
+	if err != nil {
+		log.Println("skipping git insteadOf rules, because unable to read git config:", err)
+	}
+	return append(rs, insteadOf...)
+}
+
+// configFilter skips updates that are ignored, pinned or snoozed in the user configuration.
+func configFilter(repo *gps.Repo) string {
+	return c.config.Skip(repo.Root, repo.Local.Revision)
+}
+
+// ignoreHandler handles requests to ignore an update with RepoRoot, persisting it to the
+// user configuration. If Snooze duration is provided, the update is snoozed for that long
+// instead of being ignored permanently.
+func ignoreHandler(w http.ResponseWriter, req *http.Request) error {
+	if req.Method != "POST" {
+		return httperror.Method{Allowed: []string{"POST"}}
+	}
+	root := req.PostFormValue("RepoRoot")
+	if root == "" {
+		return httperror.BadRequest{Err: fmt.Errorf("missing RepoRoot")}
+	}
+	if c.config == nil {
+		return httperror.HTTP{Code: http.StatusNotImplemented, Err: fmt.Errorf("user configuration is not available")}
+	}
+
+	var err error
+	switch snooze := req.PostFormValue("Snooze"); snooze {
+	case "":
+		err = c.config.Ignore(root)
+	default:
+		d, parseErr := time.ParseDuration(snooze)
+		if parseErr != nil || d <= 0 {
+			return httperror.BadRequest{Err: fmt.Errorf("invalid Snooze duration %q", snooze)}
+		}
+		err = c.config.Snooze(root, d)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Stop offering the update.
+	c.pipeline.Packages.Lock()
+	for i, rp := range c.pipeline.Packages.Active {
+		if rp.Repo.Root == root {
+			copy(c.pipeline.Packages.Active[i:], c.pipeline.Packages.Active[i+1:])
+			c.pipeline.Packages.Active = c.pipeline.Packages.Active[:len(c.pipeline.Packages.Active)-1]
+			delete(c.pipeline.Packages.ByRoot, root)
+			break
+		}
+	}
+	c.pipeline.Packages.Unlock()
+	return nil
+}
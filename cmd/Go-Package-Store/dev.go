@@ -125,11 +125,17 @@ var mockWorkspaceRPs = []workspace.RepoPresentation{
 			Local: struct {
 				RemoteURL string
 				Revision  string
+				Branch    string
+				Status    string
+				Diverged  bool
 			}{Revision: "abcdef0123456789000000000000000000000000"},
 			Remote: struct {
-				RepoURL  string
-				Branch   string
-				Revision string
+				RepoURL   string
+				Branch    string
+				Revision  string
+				Tracked   bool
+				Upstream  *gps.Upstream
+				LastKnown time.Time
 			}{Revision: "d34db33f01010101010101010101010101010101"},
 		},
 		Presentation: &presenter.Presentation{
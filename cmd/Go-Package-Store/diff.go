@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os/exec"
+
+	"github.com/shurcooL/Go-Package-Store"
+	"github.com/shurcooL/Go-Package-Store/presenter"
+	"github.com/shurcooL/Go-Package-Store/presenter/github"
+	"github.com/shurcooL/httperror"
+)
+
+// maxDiffSize is the maximum size of a diff that is displayed, in bytes.
+// Larger diffs are truncated.
+const maxDiffSize = 8 << 20
+
+func diffHandler(w http.ResponseWriter, req *http.Request) error {
+	if req.Method != "GET" {
+		return httperror.Method{Allowed: []string{"GET"}}
+	}
+	root := req.URL.Query().Get("root")
+	if root == "" {
+		return httperror.BadRequest{Err: fmt.Errorf("missing root query parameter")}
+	}
+	c.pipeline.Packages.Lock()
+	rp, ok := c.pipeline.Packages.ByRoot[root]
+	c.pipeline.Packages.Unlock()
+	if !ok {
+		return httperror.HTTP{Code: http.StatusNotFound, Err: fmt.Errorf("root %q not found", root)}
+	}
+	if rp.Repo.Local.Revision == "" || rp.Repo.Remote.Revision == "" {
+		return httperror.HTTP{Code: http.StatusNotFound, Err: fmt.Errorf("revisions of root %q are not known", root)}
+	}
+
+	// This might take a while.
+	diff, err := repoDiff(req.Context(), rp.Repo)
+	if err != nil {
+		return err
+	}
+	truncated := len(diff) > maxDiffSize
+	if truncated {
+		diff = diff[:maxDiffSize]
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	return diffHTML.Execute(w, diffPage{
+		Root:           root,
+		LocalRevision:  rp.Repo.Local.Revision,
+		RemoteRevision: rp.Repo.Remote.Revision,
+		Files:          parseDiff(diff),
+		Truncated:      truncated,
+	})
+}
+
+// repoDiff returns the unified diff between local and remote revisions of repo.
+// It uses the local git repository if both revisions are available there,
+// otherwise the hosting API.
+func repoDiff(ctx context.Context, repo *gps.Repo) ([]byte, error) {
+	if repo.VCS != nil && repo.Cmd != nil && repo.Cmd.Cmd == "git" &&
+		hasCommit(repo.Path, repo.Local.Revision) && hasCommit(repo.Path, repo.Remote.Revision) {
+		cmd := exec.CommandContext(ctx, "git", "diff", "--no-color", "--no-ext-diff", repo.Local.Revision, repo.Remote.Revision)
+		cmd.Dir = repo.Path
+		return cmd.Output()
+	}
+
+	diff, ok, err := github.Diff(ctx, c.githubClient, presenter.Repo{
+		Root:           repo.Root,
+		RepoURL:        c.urlRules.Rewrite(repo.Remote.RepoURL),
+		LocalRevision:  repo.Local.Revision,
+		RemoteRevision: repo.Remote.Revision,
+	})
+	if !ok {
+		return nil, httperror.HTTP{Code: http.StatusNotFound, Err: fmt.Errorf("diff of root %q is not available: remote commits are not fetched and it's not hosted on GitHub", repo.Root)}
+	}
+	return diff, err
+}
+
+// compareFork compares the remote revision of a fork with the revision of its upstream
+// via GitHub API, for forks whose upstream commits aren't available locally.
+func compareFork(root, upstreamURL, revision, upstreamRevision string) (behind int, ok bool, err error) {
+	return github.CompareFork(context.Background(), c.githubClient, root, upstreamURL, revision, upstreamRevision)
+}
+
+// hasCommit reports whether git repository at dir has commit rev.
+func hasCommit(dir, rev string) bool {
+	cmd := exec.Command("git", "cat-file", "-e", rev+"^{commit}")
+	cmd.Dir = dir
+	return cmd.Run() == nil
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"go/scanner"
+	"go/token"
+	"html/template"
+	"path"
+	"strings"
+)
+
+var diffHTML = template.Must(template.New("").Funcs(template.FuncMap{
+	"short": func(rev string) string {
+		if len(rev) > 8 {
+			return rev[:8]
+		}
+		return rev
+	},
+}).Parse(`<html>
+	<head>
+		<title>{{.Root}} - Diff - Go Package Store</title>
+		<link href="/assets/fonts/fonts.css" rel="stylesheet" type="text/css" />
+		<link href="/assets/style.css" rel="stylesheet" type="text/css" />
+	</head>
+	<body>
+		<header style="width: 100%; text-align: center;"><span style="padding: 15px; display: inline-block;">Diff</span></header>
+		<div class="center-max-width"><div class="content">
+			<h3>{{.Root}}</h3>
+			<p>Changes from <abbr title="{{.LocalRevision}}"><code class="commitID">{{short .LocalRevision}}</code></abbr>
+				to <abbr title="{{.RemoteRevision}}"><code class="commitID">{{short .RemoteRevision}}</code></abbr>
+				in {{len .Files}} files.</p>
+			{{if .Truncated}}<p class="presentation-error">Diff is too large, so it's truncated.</p>{{end}}
+			{{range .Files}}<details class="diff-file"{{if .Open}} open{{end}}>
+				<summary><span class="diff-file-name">{{.Name}}</span>
+					<span class="diffstat-added">+{{.Additions}}</span> <span class="diffstat-deleted">−{{.Deletions}}</span></summary>
+				<pre class="diff">{{range .Lines}}<span class="diff-{{.Class}}">{{.HTML}}</span>{{end}}</pre>
+			</details>
+			{{end}}
+		</div></div>
+	</body>
+</html>`))
+
+type diffPage struct {
+	Root           string
+	LocalRevision  string
+	RemoteRevision string
+	Files          []diffFile
+	Truncated      bool // Diff was truncated because it's too large.
+}
+
+// diffFile is a file in a unified diff.
+type diffFile struct {
+	Name      string // Path of the file, relative to repository root.
+	Additions int
+	Deletions int
+	Lines     []diffLine
+}
+
+// maxOpenDiffLines is the maximum number of lines in a diff of a file
+// that is expanded by default.
+const maxOpenDiffLines = 500
+
+// Open reports whether the diff of f should be expanded by default.
+func (f diffFile) Open() bool { return len(f.Lines) <= maxOpenDiffLines }
+
+type diffLine struct {
+	Class string        // One of "meta", "hunk", "added", "deleted" or "context".
+	HTML  template.HTML // Line contents, syntax highlighted for Go files.
+}
+
+// parseDiff parses a unified diff in git format.
+func parseDiff(diff []byte) []diffFile {
+	var (
+		files  []diffFile
+		f      *diffFile
+		inHunk bool
+	)
+	for _, line := range strings.Split(string(diff), "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			files = append(files, diffFile{})
+			f, inHunk = &files[len(files)-1], false
+			if i := strings.LastIndex(line, " b/"); i != -1 {
+				f.Name = line[i+len(" b/"):]
+			}
+			continue
+		case f == nil:
+			// Ignore anything before the first file.
+			continue
+		case strings.HasPrefix(line, "@@"):
+			inHunk = true
+			f.Lines = append(f.Lines, diffLine{Class: "hunk", HTML: template.HTML(template.HTMLEscapeString(line))})
+			continue
+		}
+		if !inHunk {
+			// Skip lines of the extended header that are already conveyed.
+			if line == "" || strings.HasPrefix(line, "index ") || strings.HasPrefix(line, "--- ") || strings.HasPrefix(line, "+++ ") {
+				continue
+			}
+			f.Lines = append(f.Lines, diffLine{Class: "meta", HTML: template.HTML(template.HTMLEscapeString(line))})
+			continue
+		}
+		var class string
+		switch {
+		case strings.HasPrefix(line, "+"):
+			class = "added"
+			f.Additions++
+		case strings.HasPrefix(line, "-"):
+			class = "deleted"
+			f.Deletions++
+		case strings.HasPrefix(line, " "):
+			class = "context"
+		case line == "":
+			// Trailing newline of the diff, or a line of a truncated diff.
+			continue
+		default:
+			// E.g., "\ No newline at end of file".
+			f.Lines = append(f.Lines, diffLine{Class: "meta", HTML: template.HTML(template.HTMLEscapeString(line))})
+			continue
+		}
+		html := template.HTMLEscapeString(line[1:])
+		if path.Ext(f.Name) == ".go" {
+			html = highlightGo(line[1:])
+		}
+		f.Lines = append(f.Lines, diffLine{Class: class, HTML: template.HTML(template.HTMLEscapeString(line[:1]) + html)})
+	}
+	return files
+}
+
+// highlightGo returns HTML of a line of Go source code with syntax highlighting.
+// Lines are highlighted independently, so tokens that span multiple lines,
+// such as raw strings and general comments, are only highlighted on their first line.
+func highlightGo(line string) string {
+	src := []byte(line)
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+	var s scanner.Scanner
+	s.Init(file, src, func(token.Position, string) {}, scanner.ScanComments)
+
+	var buf bytes.Buffer
+	last := 0 // Offset of the end of the last written token.
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		var class string
+		switch {
+		case tok.IsKeyword():
+			class = "go-keyword"
+		case tok == token.STRING || tok == token.CHAR:
+			class = "go-string"
+		case tok == token.COMMENT:
+			class = "go-comment"
+		case tok == token.INT || tok == token.FLOAT || tok == token.IMAG:
+			class = "go-number"
+		default:
+			continue
+		}
+		start := file.Offset(pos)
+		end := start + len(lit)
+		if lit == "" {
+			end = start + len(tok.String())
+		}
+		if start < last || end > len(src) {
+			continue
+		}
+		buf.WriteString(template.HTMLEscapeString(line[last:start]))
+		buf.WriteString(`<span class="` + class + `">`)
+		buf.WriteString(template.HTMLEscapeString(line[start:end]))
+		buf.WriteString(`</span>`)
+		last = end
+	}
+	buf.WriteString(template.HTMLEscapeString(line[last:]))
+	return buf.String()
+}
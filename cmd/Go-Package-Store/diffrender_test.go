@@ -0,0 +1,91 @@
+package main
+
+import (
+	"html/template"
+	"reflect"
+	"testing"
+)
+
+func TestParseDiff(t *testing.T) {
+	diff := "diff --git a/README.md b/README.md\n" +
+		"index 1111111..2222222 100644\n" +
+		"--- a/README.md\n" +
+		"+++ b/README.md\n" +
+		"@@ -1,2 +1,2 @@\n" +
+		" Use <b>bold</b> & \"quotes\".\n" +
+		"-Old <script>alert(1)</script>\n" +
+		"+New <img src=x onerror='alert(1)'>\n" +
+		"\\ No newline at end of file\n" +
+		"diff --git a/new.go b/new.go\n" +
+		"new file mode 100644\n" +
+		"index 0000000..3333333\n" +
+		"--- /dev/null\n" +
+		"+++ b/new.go\n" +
+		"@@ -0,0 +1 @@\n" +
+		"+var s = \"<a>\" // a < b\n"
+	want := []diffFile{
+		{
+			Name:      "README.md",
+			Additions: 1,
+			Deletions: 1,
+			Lines: []diffLine{
+				{Class: "hunk", HTML: "@@ -1,2 +1,2 @@"},
+				{Class: "context", HTML: " Use &lt;b&gt;bold&lt;/b&gt; &amp; &#34;quotes&#34;."},
+				{Class: "deleted", HTML: "-Old &lt;script&gt;alert(1)&lt;/script&gt;"},
+				{Class: "added", HTML: "+New &lt;img src=x onerror=&#39;alert(1)&#39;&gt;"},
+				{Class: "meta", HTML: `\ No newline at end of file`},
+			},
+		},
+		{
+			Name:      "new.go",
+			Additions: 1,
+			Lines: []diffLine{
+				{Class: "meta", HTML: "new file mode 100644"},
+				{Class: "hunk", HTML: "@@ -0,0 +1 @@"},
+				{Class: "added", HTML: `+<span class="go-keyword">var</span> s = <span class="go-string">&#34;&lt;a&gt;&#34;</span> <span class="go-comment">// a &lt; b</span>`},
+			},
+		},
+	}
+	if got := parseDiff([]byte(diff)); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestHighlightGo(t *testing.T) {
+	tests := []struct {
+		in   string
+		want template.HTML
+	}{
+		{
+			in:   `x := "a<b>&c"`,
+			want: `x := <span class="go-string">&#34;a&lt;b&gt;&amp;c&#34;</span>`,
+		},
+		{
+			in:   `r := '<'`,
+			want: `r := <span class="go-string">&#39;&lt;&#39;</span>`,
+		},
+		{
+			in:   "return 42 // <done> & \"dusted\"",
+			want: `<span class="go-keyword">return</span> <span class="go-number">42</span> <span class="go-comment">// &lt;done&gt; &amp; &#34;dusted&#34;</span>`,
+		},
+		{
+			in:   `/* <b> */ if x {`,
+			want: `<span class="go-comment">/* &lt;b&gt; */</span> <span class="go-keyword">if</span> x {`,
+		},
+		{
+			// Raw string that continues on the next line.
+			in:   "s := `first <line>",
+			want: "s := <span class=\"go-string\">`first &lt;line&gt;</span>",
+		},
+		{
+			// Line in the middle of a general comment isn't highlighted.
+			in:   `still <a comment>`,
+			want: `still &lt;a comment&gt;`,
+		},
+	}
+	for _, tc := range tests {
+		if got := template.HTML(highlightGo(tc.in)); got != tc.want {
+			t.Errorf("highlightGo(%q):\ngot  %s\nwant %s", tc.in, got, tc.want)
+		}
+	}
+}
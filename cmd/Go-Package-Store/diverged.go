@@ -0,0 +1,19 @@
+package main
+
+import (
+	"github.com/shurcooL/Go-Package-Store/frontend/model"
+	"github.com/shurcooL/Go-Package-Store/workspace"
+)
+
+// modelDivergence returns the model divergence of d.
+// d may be nil if local commits couldn't be determined.
+func modelDivergence(d *workspace.Divergence) *model.Divergence {
+	if d == nil {
+		return &model.Divergence{}
+	}
+	md := &model.Divergence{MergeBase: d.MergeBase}
+	for _, c := range d.LocalCommits {
+		md.LocalCommits = append(md.LocalCommits, model.Commit{Revision: c.Revision, Message: c.Message})
+	}
+	return md
+}
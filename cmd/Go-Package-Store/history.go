@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shurcooL/Go-Package-Store"
+	"github.com/shurcooL/Go-Package-Store/frontend/model"
+	"github.com/shurcooL/Go-Package-Store/history"
+	"github.com/shurcooL/Go-Package-Store/presenter"
+	"github.com/shurcooL/Go-Package-Store/workspace"
+	"github.com/shurcooL/httperror"
+)
+
+// openHistoryLog returns the history log in the user configuration directory,
+// or nil if it's not available.
+func openHistoryLog() *history.Log {
+	path, err := history.Path()
+	if err != nil {
+		log.Println("skipping persistent update history, because unable to acquire a config dir:", err)
+		return nil
+	}
+	return history.NewLog(path)
+}
+
+// restoreHistory adds the latest completed update of each repo in the history log,
+// if any, to the history of updates, so that updates of previous runs are shown too.
+// Updates that failed, were rolled back or were reverted later are skipped.
+// Restored updates have no PreviousRevision, since reverting them isn't supported.
+func restoreHistory() {
+	if c.historyLog == nil {
+		return
+	}
+	entries, err := c.historyLog.Entries()
+	if err != nil {
+		log.Println("failed to restore update history:", err)
+		return
+	}
+	latest := make(map[string]history.Entry) // Repo root -> latest completed update.
+	for _, e := range entries {
+		if e.Error != "" || (e.Verification != nil && e.Verification.RolledBack) {
+			continue
+		}
+		if prev, ok := latest[e.Root]; ok && e.From == prev.To && e.To == prev.From {
+			// The previous update was reverted.
+			delete(latest, e.Root)
+			continue
+		}
+		latest[e.Root] = e
+	}
+	var restored []history.Entry
+	for _, e := range latest {
+		restored = append(restored, e)
+	}
+	sort.Slice(restored, func(i, j int) bool { return restored[i].Time.Before(restored[j].Time) })
+
+	c.pipeline.Packages.Lock()
+	defer c.pipeline.Packages.Unlock()
+	for _, e := range restored {
+		rp := &workspace.RepoPresentation{
+			Repo: &gps.Repo{Root: e.Root},
+			Presentation: &presenter.Presentation{
+				HomeURL:  "https://" + e.Root,
+				ImageURL: "https://github.com/images/gravatars/gravatar-user-420.png",
+			},
+			UpdateState: workspace.Updated,
+		}
+		rp.Repo.Local.Revision, rp.Repo.Remote.Revision = e.From, e.To
+		if v := e.Verification; v != nil {
+			rp.Verification = &workspace.Verification{
+				Command:    v.Command,
+				Error:      v.Error,
+				Output:     v.Output,
+				RolledBack: v.RolledBack,
+			}
+		}
+		c.pipeline.Packages.History = append(c.pipeline.Packages.History, rp)
+	}
+}
+
+// logUpdate records an update of repo with specified root from one revision to another,
+// performed by updater u and verified with result v, in the history log, if any.
+func logUpdate(u gps.Updater, root, from, to string, updateError error, output string, v *workspace.Verification) {
+	if c.historyLog == nil {
+		return
+	}
+	e := history.Entry{
+		Root:    root,
+		From:    from,
+		To:      to,
+		Time:    time.Now().UTC(),
+		Updater: strings.TrimPrefix(fmt.Sprintf("%T", u), "*"),
+		Output:  output,
+	}
+	if updateError != nil {
+		e.Error = updateError.Error()
+	}
+	if v != nil {
+		e.Verification = &history.Verification{
+			Command:    v.Command,
+			Error:      v.Error,
+			Output:     v.Output,
+			RolledBack: v.RolledBack,
+		}
+	}
+	err := c.historyLog.Append(e)
+	if err != nil {
+		log.Println("failed to record update in history log:", err)
+	}
+}
+
+// historyHandler serves the history log of updates, oldest first.
+func historyHandler(w http.ResponseWriter, req *http.Request) error {
+	if req.Method != "GET" {
+		return httperror.Method{Allowed: []string{"GET"}}
+	}
+	entries := []model.HistoryEntry{}
+	if c.historyLog != nil {
+		es, err := c.historyLog.Entries()
+		if err != nil {
+			return err
+		}
+		for _, e := range es {
+			entry := model.HistoryEntry{
+				RepoRoot:       e.Root,
+				LocalRevision:  e.From,
+				RemoteRevision: e.To,
+				Time:           e.Time,
+				Updater:        e.Updater,
+				Error:          e.Error,
+				Output:         e.Output,
+			}
+			if v := e.Verification; v != nil {
+				entry.Verification = &model.Verification{
+					Command:    v.Command,
+					Error:      v.Error,
+					Output:     v.Output,
+					RolledBack: v.RolledBack,
+				}
+			}
+			entries = append(entries, entry)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	return json.NewEncoder(w).Encode(entries)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/shurcooL/Go-Package-Store/history"
+	"github.com/shurcooL/Go-Package-Store/workspace"
+)
+
+func TestRestoreHistory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	c.historyLog = history.NewLog(filepath.Join(dir, "history.jsonl"))
+	defer func() { c.historyLog = nil }()
+	c.pipeline = workspace.NewPipeline("")
+
+	t0 := time.Unix(1500000000, 0).UTC()
+	for _, e := range []history.Entry{
+		{Root: "example.com/updated", From: "a", To: "b", Time: t0.Add(2 * time.Minute)},
+		{Root: "example.com/twice", From: "a", To: "b", Time: t0},
+		{Root: "example.com/twice", From: "b", To: "c", Time: t0.Add(time.Minute)},
+		{Root: "example.com/reverted", From: "a", To: "b", Time: t0},
+		{Root: "example.com/reverted", From: "b", To: "a", Time: t0.Add(time.Minute)},
+		{Root: "example.com/failed", From: "a", To: "b", Time: t0, Error: "exit status 1"},
+		{Root: "example.com/rolledback", From: "a", To: "b", Time: t0, Verification: &history.Verification{RolledBack: true}},
+	} {
+		if err := c.historyLog.Append(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	restoreHistory()
+
+	var got []string
+	for _, rp := range c.pipeline.Packages.History {
+		if rp.UpdateState != workspace.Updated || rp.PreviousRevision != "" {
+			t.Errorf("%v: got update state %v and previous revision %q, want updated and not revertible", rp.Repo.Root, rp.UpdateState, rp.PreviousRevision)
+		}
+		got = append(got, rp.Repo.Root+" "+rp.Repo.Local.Revision+".."+rp.Repo.Remote.Revision)
+	}
+	want := []string{"example.com/twice b..c", "example.com/updated a..b"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got restored updates %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import "sync"
+
+// keyLocks is a set of locks identified by keys.
+// All keys passed to Lock are acquired at once, so locking
+// multiple keys in any order can't deadlock.
+type keyLocks struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+	held map[string]bool
+}
+
+func newKeyLocks() *keyLocks {
+	l := &keyLocks{held: make(map[string]bool)}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+// Lock locks keys, waiting until none of them are held.
+func (l *keyLocks) Lock(keys []string) {
+	l.mu.Lock()
+	for l.anyHeld(keys) {
+		l.cond.Wait()
+	}
+	for _, k := range keys {
+		l.held[k] = true
+	}
+	l.mu.Unlock()
+}
+
+// Unlock unlocks keys, which must have been locked by Lock.
+func (l *keyLocks) Unlock(keys []string) {
+	l.mu.Lock()
+	for _, k := range keys {
+		delete(l.held, k)
+	}
+	l.cond.Broadcast()
+	l.mu.Unlock()
+}
+
+// anyHeld reports whether any of keys are held. l.mu must be held.
+func (l *keyLocks) anyHeld(keys []string) bool {
+	for _, k := range keys {
+		if l.held[k] {
+			return true
+		}
+	}
+	return false
+}
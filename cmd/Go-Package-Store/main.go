@@ -15,10 +15,17 @@ import (
 	"github.com/gregjones/httpcache"
 	"github.com/gregjones/httpcache/diskcache"
 	"github.com/shurcooL/Go-Package-Store"
+	"github.com/shurcooL/Go-Package-Store/apidiff"
 	"github.com/shurcooL/Go-Package-Store/assets"
+	"github.com/shurcooL/Go-Package-Store/config"
+	"github.com/shurcooL/Go-Package-Store/history"
+	"github.com/shurcooL/Go-Package-Store/presenter/diffstat"
 	"github.com/shurcooL/Go-Package-Store/presenter/github"
 	"github.com/shurcooL/Go-Package-Store/presenter/gitiles"
+	"github.com/shurcooL/Go-Package-Store/presenter/license"
+	"github.com/shurcooL/Go-Package-Store/presenter/osv"
 	"github.com/shurcooL/Go-Package-Store/updater"
+	"github.com/shurcooL/Go-Package-Store/urlrewrite"
 	"github.com/shurcooL/Go-Package-Store/workspace"
 	"github.com/shurcooL/go/browser"
 	"github.com/shurcooL/go/ospath"
@@ -27,11 +34,30 @@ import (
 )
 
 var (
-	httpFlag       = flag.String("http", "localhost:7043", "Listen for HTTP connections on this address.")
-	stdinFlag      = flag.Bool("stdin", false, "Read the list of newline separated Go packages from stdin.")
-	depFlag        = flag.String("dep", "", "Determine the list of Go packages from the specified Gopkg.toml file.")
-	godepsFlag     = flag.String("godeps", "", "Read the list of Go packages from the specified Godeps.json file.")
-	gitSubrepoFlag = flag.String("git-subrepo", "", "Look for Go packages vendored using git-subrepo in the specified vendor directory.")
+	httpFlag           = flag.String("http", "localhost:7043", "Listen for HTTP connections on this address.")
+	stdinFlag          = flag.Bool("stdin", false, "Read the list of newline separated Go packages from stdin.")
+	depFlag            = flag.String("dep", "", "Determine the list of Go packages from the specified Gopkg.toml file.")
+	godepsFlag         = flag.String("godeps", "", "Read the list of Go packages from the specified Godeps.json file.")
+	gitSubrepoFlag     = flag.String("git-subrepo", "", "Look for Go packages vendored using git-subrepo in the specified vendor directory.")
+	githubGraphQLFlag  = flag.Bool("github-graphql", false, "Use GitHub GraphQL API to present many GitHub repos at once (requires GO_PACKAGE_STORE_GITHUB_TOKEN).")
+	githubDiffstatFlag = flag.Bool("github-diffstat", false, "With -github-graphql, fetch diffstats that can't be computed locally via GitHub API, using one extra request per repo.")
+	licenseFlag        = flag.Bool("license-change", false, "Detect license type changes in updates, which may use extra GitHub API requests.")
+	refuseLicenseFlag  = flag.Bool("refuse-license-change", false, "Refuse updating repos whose license type changed in the update, or couldn't be determined (implies -license-change).")
+	vulnDBFlag         = flag.String("vulndb", "", "Flag known vulnerabilities fixed by updates, using the OSV vulnerability database in the specified directory.")
+	apiDiffFlag        = flag.Bool("api-diff", false, "Report incompatible exported API changes in updates of git repositories (when remote commits are available locally).")
+	configFlag         = flag.String("config", "", "Read the ignore list and pins from the specified config file (default is config.json in the user config dir).")
+	verifyFlag         = flag.String("verify", "", "Verify completed updates by running \"go build\" or \"go test\" on packages that import the updated repo (one of \"build\" or \"test\").")
+	verifyRollbackFlag = flag.Bool("verify-rollback", false, "Roll back updates that fail verification (see -verify flag).")
+	dryRunFlag         = flag.Bool("dry-run", false, "Only preview what updating would do, without updating anything (batch updates can't be previewed).")
+	parallelFlag       = flag.Int("parallel", 4, "Maximum number of updates to perform concurrently.")
+	dirtyFlag          = flag.Bool("dirty", false, "Present updates of repos with dirty working trees, with a warning.")
+	forksFlag          = flag.Bool("forks", false, "Present updates of git repos cloned from forks, and how far the forks are behind their canonical upstream.")
+	fetchForksFlag     = flag.Bool("fetch-forks", false, "With -forks, fetch upstream commits into local repos of forks not hosted on GitHub, to determine how far they're behind (modifies the local repos).")
+	offlineFlag        = flag.Bool("offline", false, "Use the last-known remote state of repos from previous runs, rather than fetching it over the network.")
+	refreshRootsFlag   = flag.Bool("refresh-repo-roots", false, "Resolve repository roots of import paths over the network, rather than using cached ones.")
+	trackUpstreamFlag  = flag.Bool("track-upstream", false, "Track the upstream branch of checked out branches of git repos, rather than the remote default branch.")
+	divergedFlag       = flag.Bool("diverged", false, "Present updates of git repos with local commits that aren't on remote, offering to rebase the local commits.")
+	stashFlag          = flag.Bool("stash", false, "Stash local changes of repos with dirty working trees while updating them (implies -dirty).")
 )
 
 func usage() {
@@ -45,6 +71,24 @@ GitHub Access Token:
   a GitHub access token for Go Package Store to use via the
   GO_PACKAGE_STORE_GITHUB_TOKEN environment variable.
 
+Config File:
+  Updates can be ignored, pinned or snoozed in a JSON config file
+  (see -config flag). For example:
+
+    {
+      "Ignore": ["github.com/foo/bar", "golang.org/x/..."],
+      "Pins": [{"Root": "github.com/baz/qux", "Revision": "<commit>"}]
+    }
+
+  The Ignore and Snooze actions on each update are saved there too.
+
+  Repos cloned from mirrors are recognized via Mirrors in the config file,
+  as well as via url.<base>.insteadOf rules in git config. For example:
+
+    {
+      "Mirrors": [{"URL": "https://mirror.example.com/github/", "Canonical": "https://github.com/"}]
+    }
+
 Examples:
   # Check for updates for all Go packages in GOPATH.
   Go-Package-Store
@@ -58,24 +102,84 @@ Examples:
   # Show updates for all Go packages vendored using git-subrepo
   # in the specified vendor directory.
   Go-Package-Store -git-subrepo=/path/to/repo/vendor
+
+  # Show updates as of the last run, without network access.
+  Go-Package-Store -offline
 `)
 }
 
 func main() {
 	flag.Usage = usage
 	flag.Parse()
+	switch *verifyFlag {
+	case "", "build", "test":
+	default:
+		fmt.Fprintf(os.Stderr, "invalid -verify flag value %q, must be one of \"build\" or \"test\"\n", *verifyFlag)
+		flag.Usage()
+		os.Exit(2)
+	}
 
 	log.SetFlags(0)
 
 	c.pipeline = workspace.NewPipeline(wd)
 	registerPresenters(c.pipeline)
+	if cache := openRepoRootCache(*refreshRootsFlag, *offlineFlag); cache != nil {
+		c.pipeline.ResolveRepoRootsWith(cache.RepoRootForImportPath)
+	}
+	switch cache := openStateCache(); {
+	case cache != nil:
+		c.pipeline.RememberStateIn(cache)
+		if *offlineFlag {
+			c.pipeline.Offline()
+		}
+	case *offlineFlag:
+		log.Fatalln("-offline requires the last-known state cache, but it's not available")
+	}
+	c.config = openConfig(*configFlag)
+	if c.config != nil {
+		c.pipeline.RegisterFilter(configFilter)
+	}
+	c.urlRules = urlRewriteRules()
+	c.pipeline.RegisterURLRewriter(c.urlRules.Rewrite)
+	if *dirtyFlag || *stashFlag {
+		c.pipeline.PresentDirty()
+	}
+	if *forksFlag {
+		c.pipeline.PresentForks()
+		c.pipeline.CompareForksWith(compareFork)
+		if *fetchForksFlag {
+			c.pipeline.FetchForks()
+		}
+	}
+	if *trackUpstreamFlag {
+		c.pipeline.TrackUpstream()
+	}
+	if *divergedFlag {
+		c.pipeline.PresentDiverged()
+	}
 	c.updater = populatePipelineAndCreateUpdater(c.pipeline)
+	if c.updater != nil && *dryRunFlag {
+		c.updater = updater.DryRun{Updater: c.updater}
+	}
+	c.historyLog = openHistoryLog()
+	restoreHistory()
 	if c.updater != nil {
-		updateWorker := newUpdateWorker(c.updater)
+		updateWorker := newUpdateWorker(c.updater, *parallelFlag)
 		updateWorker.Start()
 		http.Handle("/api/update", errorHandler(updateWorker.Handler))
+		if _, ok := c.updater.(gps.Reverter); ok {
+			http.Handle("/api/revert", errorHandler(updateWorker.RevertHandler))
+			http.Handle("/api/update-batch", errorHandler(updateWorker.BatchHandler))
+		}
+		if _, ok := c.updater.(gps.Planner); ok {
+			http.Handle("/api/plan", errorHandler(planHandler))
+		}
 	}
 	http.Handle("/api/updates", errorHandler(updatesHandler))
+	http.Handle("/api/status", errorHandler(statusHandler))
+	http.Handle("/api/ignore", errorHandler(ignoreHandler))
+	http.Handle("/api/history", errorHandler(historyHandler))
+	http.Handle("/diff", errorHandler(diffHandler))
 	http.Handle("/updates", errorHandler(indexHandler))
 	assetsFS := httpgzip.FileServer(assets.Assets, httpgzip.FileServerOptions{ServeError: httpgzip.Detailed})
 	http.Handle("/assets/", assetsFS)
@@ -111,6 +215,21 @@ var c = struct {
 	// It's used to update repos in the backend, and if set to nil, to disable
 	// the frontend UI for updating packages.
 	updater gps.Updater
+
+	// githubClient is the HTTP client for accessing the GitHub API.
+	// It's used to fetch diffs of repos whose remote commits are not available locally.
+	githubClient *http.Client
+
+	// config is the user configuration with the ignore list and pins.
+	// If nil, the frontend UI for ignoring and snoozing updates is disabled.
+	config *config.File
+
+	// historyLog is the persistent log of updates. If nil, updates aren't recorded.
+	historyLog *history.Log
+
+	// urlRules rewrite remote URLs to their canonical form. They're used to compare
+	// URLs and to recognize hosting services, not for network operations.
+	urlRules urlrewrite.Rules
 }{}
 
 func registerPresenters(pipeline *workspace.Pipeline) {
@@ -123,6 +242,7 @@ func registerPresenters(pipeline *workspace.Pipeline) {
 	}
 
 	// Register GitHub presenter.
+	var githubClient *http.Client
 	{
 		var transport http.RoundTripper
 
@@ -141,7 +261,22 @@ func registerPresenters(pipeline *workspace.Pipeline) {
 			}
 		}
 
-		pipeline.RegisterPresenter(github.NewPresenter(&http.Client{Transport: transport}))
+		githubClient = &http.Client{Transport: transport}
+		c.githubClient = githubClient
+		pipeline.RegisterPresenter(github.NewPresenter(githubClient))
+	}
+
+	// Optionally, register GitHub GraphQL batch presenter.
+	if *githubGraphQLFlag {
+		// GitHub GraphQL API requires authentication.
+		if token := os.Getenv("GO_PACKAGE_STORE_GITHUB_TOKEN"); token != "" {
+			transport := &oauth2.Transport{
+				Source: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}),
+			}
+			pipeline.RegisterBatchPresenter(github.NewBatchPresenter(&http.Client{Transport: transport}))
+		} else {
+			log.Println("skipping GitHub GraphQL batch presenter, because GO_PACKAGE_STORE_GITHUB_TOKEN env var is not set")
+		}
 	}
 
 	// Register Gitiles presenter.
@@ -158,6 +293,35 @@ func registerPresenters(pipeline *workspace.Pipeline) {
 
 		pipeline.RegisterPresenter(gitiles.NewPresenter(&http.Client{Transport: transport}))
 	}
+
+	// Register local diffstat enricher, as a fallback for presenters that don't provide one.
+	pipeline.RegisterEnricher(diffstat.NewEnricher())
+	if *githubGraphQLFlag && *githubDiffstatFlag {
+		// GitHub GraphQL API doesn't provide diffstats, so optionally fill in the ones of batch
+		// presented GitHub repos via GitHub API, when they can't be computed locally.
+		// It's opt-in, because it costs a request per repo, which batching is meant to save.
+		pipeline.RegisterEnricher(github.NewDiffstatEnricher(githubClient))
+	}
+
+	// Optionally, register incompatible API changes enricher.
+	if *apiDiffFlag {
+		pipeline.RegisterEnricher(apidiff.NewEnricher())
+	}
+
+	// Optionally, register license change enricher.
+	if *licenseFlag || *refuseLicenseFlag {
+		pipeline.RegisterEnricher(license.NewEnricher(github.NewLicenseFetcher(githubClient)))
+	}
+
+	// Optionally, register OSV vulnerability database enricher.
+	if *vulnDBFlag != "" {
+		enricher, err := osv.NewEnricher(*vulnDBFlag)
+		if err != nil {
+			log.Println("skipping known vulnerabilities, because unable to load vulnerability database:", err)
+		} else {
+			pipeline.RegisterEnricher(enricher)
+		}
+	}
 }
 
 func populatePipelineAndCreateUpdater(pipeline *workspace.Pipeline) gps.Updater {
@@ -174,7 +338,7 @@ func populatePipelineAndCreateUpdater(pipeline *workspace.Pipeline) gps.Updater
 			})
 			pipeline.Done()
 		}()
-		return updater.Gopath{}
+		return updater.Gopath{Stash: *stashFlag}
 	case *stdinFlag:
 		fmt.Println("Reading the list of newline separated Go packages from stdin.")
 		go func() { // This needs to happen in the background because sending input will be blocked on processing.
@@ -185,7 +349,7 @@ func populatePipelineAndCreateUpdater(pipeline *workspace.Pipeline) gps.Updater
 			}
 			pipeline.Done()
 		}()
-		return updater.Gopath{}
+		return updater.Gopath{Stash: *stashFlag}
 	case *depFlag != "":
 		// Check dep binary exists in PATH.
 		if _, err := exec.LookPath("dep"); err != nil {
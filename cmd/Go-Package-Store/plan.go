@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/shurcooL/Go-Package-Store"
+	"github.com/shurcooL/Go-Package-Store/frontend/model"
+	"github.com/shurcooL/httperror"
+)
+
+// planHandler serves what updating repo with specified root would do,
+// without updating it. It's only available if the updater is a gps.Planner.
+func planHandler(w http.ResponseWriter, req *http.Request) error {
+	if req.Method != "GET" {
+		return httperror.Method{Allowed: []string{"GET"}}
+	}
+	planner, ok := c.updater.(gps.Planner)
+	if !ok {
+		return httperror.HTTP{Code: http.StatusNotImplemented, Err: fmt.Errorf("previewing updates is not supported by updater %T", c.updater)}
+	}
+	root := req.URL.Query().Get("root")
+	if root == "" {
+		return httperror.BadRequest{Err: fmt.Errorf("missing root query parameter")}
+	}
+	c.pipeline.Packages.Lock()
+	rp, ok := c.pipeline.Packages.ByRoot[root]
+	c.pipeline.Packages.Unlock()
+	if !ok {
+		return httperror.HTTP{Code: http.StatusNotFound, Err: fmt.Errorf("root %q not found", root)}
+	}
+
+	plan, err := planner.Plan(rp.Repo)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	return json.NewEncoder(w).Encode(model.Plan{
+		LocalRevision:  rp.Repo.Local.Revision,
+		RemoteRevision: rp.Repo.Remote.Revision,
+		Commands:       plan.Commands,
+		Edits:          plan.Edits,
+		DryRun:         *dryRunFlag,
+	})
+}
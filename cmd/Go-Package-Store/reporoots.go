@@ -0,0 +1,31 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	"github.com/shurcooL/Go-Package-Store/rootcache"
+)
+
+// repoRootCacheTTL is how long resolved repository roots are cached for.
+const repoRootCacheTTL = 7 * 24 * time.Hour
+
+// openRepoRootCache returns the repository root cache in the user cache directory,
+// or nil if it's not available. If refresh is true, cached repository roots are
+// ignored, and replaced by freshly resolved ones. If offline is true, expired
+// repository roots are used, and none are resolved over the network; refresh is ignored.
+func openRepoRootCache(refresh, offline bool) *rootcache.Cache {
+	path, err := rootcache.Path()
+	if err != nil {
+		log.Println("skipping repository root cache, because unable to acquire a cache dir:", err)
+		return nil
+	}
+	if refresh && !offline {
+		return rootcache.New(path, repoRootCacheTTL)
+	}
+	cache := rootcache.Open(path, repoRootCacheTTL)
+	if offline {
+		cache.Offline()
+	}
+	return cache
+}
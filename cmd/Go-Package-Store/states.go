@@ -0,0 +1,18 @@
+package main
+
+import (
+	"log"
+
+	"github.com/shurcooL/Go-Package-Store/statecache"
+)
+
+// openStateCache returns the cache of last-known state of repos in the user cache directory,
+// or nil if it's not available.
+func openStateCache() *statecache.Cache {
+	path, err := statecache.Path()
+	if err != nil {
+		log.Println("skipping last-known state cache, because unable to acquire a cache dir:", err)
+		return nil
+	}
+	return statecache.Open(path)
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/shurcooL/Go-Package-Store/frontend/model"
+	"github.com/shurcooL/httperror"
+)
+
+func statusHandler(w http.ResponseWriter, req *http.Request) error {
+	if req.Method != "GET" {
+		return httperror.Method{Allowed: []string{"GET"}}
+	}
+	var status model.Status
+	if rl, ok := c.pipeline.RateLimited(); ok {
+		status.RateLimit = &model.RateLimit{
+			Service: rl.Service,
+			Reset:   rl.Reset,
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	return json.NewEncoder(w).Encode(status)
+}
@@ -1,29 +1,53 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"os"
+	"sync"
 
 	"github.com/shurcooL/Go-Package-Store"
+	"github.com/shurcooL/Go-Package-Store/frontend/model"
+	"github.com/shurcooL/Go-Package-Store/presenter/license"
+	"github.com/shurcooL/Go-Package-Store/updater"
 	"github.com/shurcooL/Go-Package-Store/workspace"
 	"github.com/shurcooL/httperror"
 )
 
-func newUpdateWorker(updater gps.Updater) updateWorker {
+func newUpdateWorker(updater gps.Updater, parallel int) updateWorker {
+	if parallel < 1 {
+		parallel = 1
+	}
 	return updateWorker{
 		updater:        updater,
 		updateRequests: make(chan updateRequest),
+		parallel:       make(chan struct{}, parallel),
+		locks:          newKeyLocks(),
+		workspace:      new(sync.RWMutex),
 	}
 }
 
 type updateWorker struct {
 	updater        gps.Updater
 	updateRequests chan updateRequest
+
+	// parallel limits the number of requests that are processed concurrently.
+	parallel chan struct{}
+	// locks serialize requests that modify the same repos or shared state.
+	locks *keyLocks
+	// workspace is held for reading while repos are modified,
+	// and for writing while the workspace is verified.
+	workspace *sync.RWMutex
 }
 
 type updateRequest struct {
 	Root         string
+	Revert       bool     // Revert a completed update, rather than update.
+	Batch        []string // Roots to update as a batch, rather than Root.
 	ResponseChan chan error
 }
 
@@ -39,65 +63,292 @@ func (u updateWorker) Handler(w http.ResponseWriter, req *http.Request) error {
 	}
 	u.updateRequests <- ur
 
-	err := <-ur.ResponseChan
-	// TODO: Display error in frontend.
-	if err != nil {
+	var result model.UpdateResult
+	switch err := (<-ur.ResponseChan).(type) {
+	case nil:
+	case updater.StashError:
+		// The update was completed, only the local changes weren't re-applied.
+		log.Println("update stash error:", err)
+		result.StashConflict = &model.StashConflict{Error: err.Error(), Files: err.Conflicts}
+	default:
 		log.Println("update error:", err)
+		result.Error = err.Error()
 	}
 
-	return nil
+	// Include the result of verifying the update, if any.
+	c.pipeline.Packages.Lock()
+	if rp, ok := c.pipeline.Packages.ByRoot[ur.Root]; ok && rp.Verification != nil {
+		result.Verification = modelVerification(rp.Verification)
+	}
+	c.pipeline.Packages.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	return json.NewEncoder(w).Encode(result)
+}
+
+// RevertHandler handles requests to revert a completed update with RepoRoot
+// to the previous revision. It's only available if the updater is a gps.Reverter.
+func (u updateWorker) RevertHandler(w http.ResponseWriter, req *http.Request) error {
+	if req.Method != "POST" {
+		return httperror.Method{Allowed: []string{"POST"}}
+	}
+
+	ur := updateRequest{
+		Root:         req.PostFormValue("RepoRoot"),
+		Revert:       true,
+		ResponseChan: make(chan error),
+	}
+	u.updateRequests <- ur
+
+	err := <-ur.ResponseChan
+	if err != nil {
+		log.Println("revert error:", err)
+	}
+	return err
 }
 
-// Start performing sequential updates of Go packages. It does not update
-// in parallel to avoid race conditions.
+// Start performing updates of Go packages. Up to the parallel limit of updates
+// are performed concurrently, but updates that modify the same repos or shared state,
+// such as a manifest file, are performed one at a time to avoid race conditions.
 func (u updateWorker) Start() {
 	go u.run()
 }
 
 func (u updateWorker) run() {
 	for ur := range u.updateRequests {
+		go func(ur updateRequest) {
+			keys := u.lockKeys(ur)
+			u.locks.Lock(keys)
+			defer u.locks.Unlock(keys)
+			u.parallel <- struct{}{}
+			defer func() { <-u.parallel }()
+
+			switch {
+			case ur.Revert:
+				ur.ResponseChan <- u.revert(ur.Root)
+			case len(ur.Batch) > 0:
+				ur.ResponseChan <- u.updateBatch(ur.Batch)
+			default:
+				ur.ResponseChan <- u.updateOne(ur.Root)
+			}
+		}(ur)
+	}
+}
+
+// lockKeys returns the keys to lock while processing ur,
+// which identify the repos and shared state that ur modifies.
+func (u updateWorker) lockKeys(ur updateRequest) []string {
+	roots := ur.Batch
+	if len(roots) == 0 {
+		roots = []string{ur.Root}
+	}
+	var keys []string
+	for _, root := range roots {
+		keys = append(keys, "root:"+root)
+		ssu, ok := u.updater.(gps.SharedStateUpdater)
+		if !ok {
+			continue
+		}
 		c.pipeline.Packages.Lock()
-		rp, ok := c.pipeline.Packages.ByRoot[ur.Root]
+		rp, ok := c.pipeline.Packages.ByRoot[root]
 		c.pipeline.Packages.Unlock()
 		if !ok {
-			ur.ResponseChan <- fmt.Errorf("root %q not found", ur.Root)
 			continue
 		}
-		if rp.UpdateState != workspace.Available {
-			ur.ResponseChan <- fmt.Errorf("root %q not available for update: %v", ur.Root, rp.UpdateState)
-			continue
+		for _, s := range ssu.SharedState(rp.Repo) {
+			keys = append(keys, "shared:"+s)
 		}
+	}
+	return keys
+}
+
+// updateOne updates repo with specified root.
+// If the update was completed, but stashed local changes couldn't be re-applied,
+// repo is marked as updated, and an updater.StashError is returned.
+func (u updateWorker) updateOne(root string) error {
+	c.pipeline.Packages.Lock()
+	rp, ok := c.pipeline.Packages.ByRoot[root]
+	if !ok {
+		c.pipeline.Packages.Unlock()
+		return fmt.Errorf("root %q not found", root)
+	}
+	if rp.UpdateState != workspace.Available {
+		c.pipeline.Packages.Unlock()
+		return fmt.Errorf("root %q not available for update: %v", root, rp.UpdateState)
+	}
+	if reason := updateRefusal(rp); reason != "" {
+		c.pipeline.Packages.Unlock()
+		return fmt.Errorf("refusing to update root %q: %v", root, reason)
+	}
+	// Mark repo as updating.
+	rp.UpdateState = workspace.Updating
+	c.pipeline.Packages.Unlock()
 
-		// Mark repo as updating.
+	var output bytes.Buffer
+	updateError := u.update(rp.Repo, io.MultiWriter(os.Stdout, &output))
+	var verification *workspace.Verification
+	if updateError == nil {
+		verification = u.verify(rp, *verifyRollbackFlag)
+	}
+	stashError, stashConflicted := updateError.(updater.StashError)
+	if stashConflicted {
+		// The update was completed. Conflicts are left in the working tree
+		// for the user to resolve, so it isn't verified.
+		updateError = nil
+	}
+	logUpdate(u.updater, rp.Repo.Root, rp.Repo.Local.Revision, rp.Repo.Remote.Revision, updateError, output.String(), verification)
+
+	c.pipeline.Packages.Lock()
+	rp.Verification = verification
+	c.pipeline.Packages.Unlock()
+
+	switch {
+	case updateError != nil, verification != nil && verification.RolledBack:
+		// Mark repo as available for update again, so it can be retried.
 		c.pipeline.Packages.Lock()
-		c.pipeline.Packages.ByRoot[ur.Root].UpdateState = workspace.Updating
+		rp.UpdateState = workspace.Available
 		c.pipeline.Packages.Unlock()
+	default:
+		markUpdated(rp)
+	}
+
+	fmt.Println("\nDone.")
+	if stashConflicted {
+		return stashError
+	}
+	return updateError
+}
+
+// update updates repo, writing the output of updating to w if the updater supports it.
+// Diverged repos are updated by rebasing local commits onto the remote revision.
+func (u updateWorker) update(repo *gps.Repo, w io.Writer) error {
+	u.workspace.RLock()
+	defer u.workspace.RUnlock()
+	if repo.Local.Diverged {
+		rebaser, ok := u.updater.(gps.Rebaser)
+		if !ok {
+			return fmt.Errorf("updating diverged repos is not supported by updater %T, because it can't rebase", u.updater)
+		}
+		return rebaser.Rebase(repo, w)
+	}
+	if ou, ok := u.updater.(gps.OutputUpdater); ok {
+		return ou.UpdateOutput(repo, w)
+	}
+	return u.updater.Update(repo)
+}
+
+// revertTo reverts repo to revision, writing the output of reverting to w.
+func (u updateWorker) revertTo(reverter gps.Reverter, repo *gps.Repo, revision string, w io.Writer) error {
+	u.workspace.RLock()
+	defer u.workspace.RUnlock()
+	return reverter.Revert(repo, revision, w)
+}
 
-		updateError := u.updater.Update(rp.Repo)
+// verify verifies a completed update of rp, as configured by -verify flag.
+// If verification fails and rollback is true, the update is rolled back.
+// No repos are modified by other updates while verifying.
+// It returns nil if there's nothing to verify.
+func (u updateWorker) verify(rp *workspace.RepoPresentation, rollback bool) *workspace.Verification {
+	if *verifyFlag == "" {
+		return nil
+	}
+	u.workspace.Lock()
+	defer u.workspace.Unlock()
+	return verifyUpdate(rp, os.Stdout, rollback)
+}
 
-		if updateError == nil {
-			c.pipeline.Packages.Lock()
-			for i, rp := range c.pipeline.Packages.Active {
-				if rp.Repo.Root == ur.Root {
-					// Remove from active.
-					copy(c.pipeline.Packages.Active[i:], c.pipeline.Packages.Active[i+1:])
-					c.pipeline.Packages.Active = c.pipeline.Packages.Active[:len(c.pipeline.Packages.Active)-1]
+// markUpdated moves rp from active to history, and marks it as updated.
+func markUpdated(rp *workspace.RepoPresentation) {
+	c.pipeline.Packages.Lock()
+	defer c.pipeline.Packages.Unlock()
+	for i, arp := range c.pipeline.Packages.Active {
+		if arp == rp {
+			// Remove from active.
+			copy(c.pipeline.Packages.Active[i:], c.pipeline.Packages.Active[i+1:])
+			c.pipeline.Packages.Active = c.pipeline.Packages.Active[:len(c.pipeline.Packages.Active)-1]
 
-					// Mark repo as updated.
-					rp.UpdateState = workspace.Updated
+			// Mark repo as updated.
+			rp.UpdateState = workspace.Updated
+			rp.PreviousRevision = rp.Repo.Local.Revision
 
-					// Append to history.
-					c.pipeline.Packages.History = append(c.pipeline.Packages.History, rp)
+			// Append to history.
+			c.pipeline.Packages.History = append(c.pipeline.Packages.History, rp)
 
-					break
-				}
-			}
-			c.pipeline.Packages.Unlock()
+			return
+		}
+	}
+}
+
+// revert reverts a completed update of repo with specified root to the previous revision,
+// and makes the update available again.
+func (u updateWorker) revert(root string) error {
+	reverter, ok := u.updater.(gps.Reverter)
+	if !ok {
+		return fmt.Errorf("reverting is not supported by updater %T", u.updater)
+	}
+	c.pipeline.Packages.Lock()
+	rp, ok := c.pipeline.Packages.ByRoot[root]
+	if !ok {
+		c.pipeline.Packages.Unlock()
+		return fmt.Errorf("root %q not found", root)
+	}
+	updateState, previousRevision := rp.UpdateState, rp.PreviousRevision
+	c.pipeline.Packages.Unlock()
+	if updateState != workspace.Updated || previousRevision == "" {
+		return fmt.Errorf("root %q has no completed update to revert", root)
+	}
+
+	var output bytes.Buffer
+	err := u.revertTo(reverter, rp.Repo, previousRevision, io.MultiWriter(os.Stdout, &output))
+	logUpdate(u.updater, root, rp.Repo.Remote.Revision, previousRevision, err, output.String(), nil)
+	fmt.Println("\nDone.")
+	if err != nil {
+		return err
+	}
+
+	c.pipeline.Packages.Lock()
+	for i, hrp := range c.pipeline.Packages.History {
+		if hrp == rp {
+			// Remove from history.
+			copy(c.pipeline.Packages.History[i:], c.pipeline.Packages.History[i+1:])
+			c.pipeline.Packages.History = c.pipeline.Packages.History[:len(c.pipeline.Packages.History)-1]
+			break
 		}
+	}
+	// Mark repo as available for update again.
+	rp.UpdateState = workspace.Available
+	rp.PreviousRevision = ""
+	rp.Verification = nil
+	c.pipeline.Packages.Active = append(c.pipeline.Packages.Active, rp)
+	c.pipeline.Packages.Unlock()
+	return nil
+}
 
-		ur.ResponseChan <- updateError
-		fmt.Println("\nDone.")
+// updateRefusal returns the reason why updating rp is refused,
+// or empty string if updating it is allowed.
+func updateRefusal(rp *workspace.RepoPresentation) string {
+	if _, ok := c.updater.(updater.DryRun); ok {
+		return "dry run mode is enabled, use Preview to see what updating would do"
+	}
+	if !rp.Repo.Remote.LastKnown.IsZero() && *offlineFlag {
+		return "offline mode is enabled, updating requires the remote"
+	}
+	if rp.Repo.Remote.Upstream != nil && rp.Repo.Local.Revision == rp.Repo.Remote.Revision {
+		return "fork is up to date, upstream updates need to be merged into the fork first"
+	}
+	if _, ok := c.updater.(gps.Rebaser); rp.Repo.Local.Diverged && !ok {
+		return "local branch has diverged from remote, and rebasing it is not supported"
+	}
+	if rp.Repo.Local.Status != "" && !*stashFlag {
+		return "working tree is dirty, use -stash flag to stash local changes while updating"
+	}
+	if lc := rp.Presentation.LicenseChange; *refuseLicenseFlag && lc != nil {
+		if lc.From == license.Unknown || lc.To == license.Unknown {
+			return "license type couldn't be determined"
+		}
+		return fmt.Sprintf("license changed from %v to %v", lc.From, lc.To)
 	}
+	return ""
 }
 
 // TODO: Currently lots of logic (for manipulating repo presentations as they
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shurcooL/Go-Package-Store"
+	"github.com/shurcooL/Go-Package-Store/presenter"
+	"github.com/shurcooL/Go-Package-Store/updater"
+	"github.com/shurcooL/Go-Package-Store/workspace"
+)
+
+// fakeUpdater is an updater that records how many updates it performs concurrently.
+type fakeUpdater struct {
+	mu       sync.Mutex
+	running  map[string]int // Root -> number of updates of the repo in progress.
+	current  int            // Number of updates in progress.
+	max      int            // Maximum number of updates that were in progress at once.
+	sameRoot bool           // Whether the same repo was ever updated concurrently.
+}
+
+func (u *fakeUpdater) Update(repo *gps.Repo) error {
+	u.mu.Lock()
+	u.running[repo.Root]++
+	if u.running[repo.Root] > 1 {
+		u.sameRoot = true
+	}
+	u.current++
+	if u.current > u.max {
+		u.max = u.current
+	}
+	u.mu.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	u.mu.Lock()
+	u.running[repo.Root]--
+	u.current--
+	u.mu.Unlock()
+	return nil
+}
+
+// fakeSharedStateUpdater is a fakeUpdater whose updates all modify a single manifest file.
+type fakeSharedStateUpdater struct {
+	*fakeUpdater
+}
+
+func (fakeSharedStateUpdater) SharedState(*gps.Repo) []string { return []string{"Gopkg.lock"} }
+
+func TestUpdateWorker(t *testing.T) {
+	const (
+		repos    = 10
+		parallel = 3
+	)
+	tests := []struct {
+		name        string
+		updater     func(*fakeUpdater) gps.Updater
+		wantMaxLow  int
+		wantMaxHigh int
+	}{
+		{
+			name:        "independent",
+			updater:     func(u *fakeUpdater) gps.Updater { return u },
+			wantMaxLow:  2,
+			wantMaxHigh: parallel,
+		},
+		{
+			name:        "shared state",
+			updater:     func(u *fakeUpdater) gps.Updater { return fakeSharedStateUpdater{u} },
+			wantMaxLow:  1,
+			wantMaxHigh: 1,
+		},
+	}
+	for _, tc := range tests {
+		fu := &fakeUpdater{running: make(map[string]int)}
+		c.updater = tc.updater(fu)
+		c.pipeline = workspace.NewPipeline("")
+		var roots []string
+		for i := 0; i < repos; i++ {
+			root := fmt.Sprintf("example.com/repo%d", i)
+			roots = append(roots, root)
+			c.pipeline.AddPresented(&workspace.RepoPresentation{
+				Repo:         &gps.Repo{Root: root},
+				Presentation: &presenter.Presentation{},
+			})
+		}
+		c.pipeline.Done()
+		for range c.pipeline.RepoPresentations() {
+		}
+
+		u := newUpdateWorker(c.updater, parallel)
+		u.Start()
+		var wg sync.WaitGroup
+		// Request each repo to be updated twice, the second request must fail
+		// because the repo is no longer available for update.
+		errs := make(chan error, 2*repos)
+		for _, root := range append(roots, roots...) {
+			wg.Add(1)
+			go func(root string) {
+				defer wg.Done()
+				ur := updateRequest{Root: root, ResponseChan: make(chan error)}
+				u.updateRequests <- ur
+				errs <- <-ur.ResponseChan
+			}(root)
+		}
+		wg.Wait()
+		close(u.updateRequests)
+		close(errs)
+
+		var failed int
+		for err := range errs {
+			if err != nil {
+				failed++
+			}
+		}
+		if failed != repos {
+			t.Errorf("%s: got %d failed updates, want %d", tc.name, failed, repos)
+		}
+		if fu.sameRoot {
+			t.Errorf("%s: same repo was updated concurrently", tc.name)
+		}
+		if fu.max < tc.wantMaxLow || fu.max > tc.wantMaxHigh {
+			t.Errorf("%s: got %d concurrent updates, want between %d and %d", tc.name, fu.max, tc.wantMaxLow, tc.wantMaxHigh)
+		}
+		for _, root := range roots {
+			if got := c.pipeline.Packages.ByRoot[root].UpdateState; got != workspace.Updated {
+				t.Errorf("%s: %s: got update state %v, want %v", tc.name, root, got, workspace.Updated)
+			}
+		}
+	}
+}
+
+// failingUpdater is an updater whose updates always fail.
+type failingUpdater struct{}
+
+func (failingUpdater) Update(*gps.Repo) error {
+	return fmt.Errorf("rebasing local commits conflicted in:\nfile.go\n")
+}
+
+func TestUpdateFailure(t *testing.T) {
+	const root = "example.com/repo"
+	c.updater = failingUpdater{}
+	c.pipeline = workspace.NewPipeline("")
+	c.pipeline.AddPresented(&workspace.RepoPresentation{
+		Repo:         &gps.Repo{Root: root},
+		Presentation: &presenter.Presentation{},
+	})
+	c.pipeline.Done()
+	for range c.pipeline.RepoPresentations() {
+	}
+
+	u := newUpdateWorker(c.updater, 1)
+	if err := u.updateOne(root); err == nil {
+		t.Fatal("got no error, want update error")
+	}
+	// A failed update can be retried.
+	if got := c.pipeline.Packages.ByRoot[root].UpdateState; got != workspace.Available {
+		t.Errorf("got update state %v, want %v", got, workspace.Available)
+	}
+	if err := u.updateOne(root); err == nil || !strings.Contains(err.Error(), "conflicted") {
+		t.Errorf("got error %v on retry, want update error", err)
+	}
+}
+
+// stashConflictUpdater is an updater whose updates are completed,
+// but stashed local changes can't be re-applied.
+type stashConflictUpdater struct{}
+
+func (stashConflictUpdater) Update(*gps.Repo) error {
+	return updater.StashError{Conflicts: []string{"file.go"}, Err: fmt.Errorf("exit status 1")}
+}
+
+func TestUpdateStashConflict(t *testing.T) {
+	const root = "example.com/repo"
+	c.updater = stashConflictUpdater{}
+	c.pipeline = workspace.NewPipeline("")
+	repo := &gps.Repo{Root: root}
+	repo.Local.Revision = "local"
+	c.pipeline.AddPresented(&workspace.RepoPresentation{
+		Repo:         repo,
+		Presentation: &presenter.Presentation{},
+	})
+	c.pipeline.Done()
+	for range c.pipeline.RepoPresentations() {
+	}
+
+	u := newUpdateWorker(c.updater, 1)
+	if _, ok := u.updateOne(root).(updater.StashError); !ok {
+		t.Error("got no stash error, want it")
+	}
+	// The update was completed, so it can be reverted.
+	rp := c.pipeline.Packages.ByRoot[root]
+	if rp.UpdateState != workspace.Updated || rp.PreviousRevision != "local" {
+		t.Errorf("got update state %v and previous revision %q, want %v and %q", rp.UpdateState, rp.PreviousRevision, workspace.Updated, "local")
+	}
+}
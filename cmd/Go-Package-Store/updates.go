@@ -4,8 +4,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"path"
+	"sort"
+	"strings"
 
+	"github.com/shurcooL/Go-Package-Store"
 	"github.com/shurcooL/Go-Package-Store/frontend/model"
+	"github.com/shurcooL/Go-Package-Store/presenter"
 	"github.com/shurcooL/httperror"
 )
 
@@ -22,24 +27,86 @@ func updatesHandler(w http.ResponseWriter, req *http.Request) error {
 		return fmt.Errorf("ResponseWriter %v is not a Flusher", w)
 	}
 	for rp := range c.pipeline.RepoPresentations() {
+		c.pipeline.Packages.Lock()
+		importedBy := rp.Analysis.ImportedBy
+		c.pipeline.Packages.Unlock()
+
 		var cs []model.Change
 		for _, c := range rp.Presentation.Changes {
 			cs = append(cs, model.Change{
 				Message:  c.Message,
+				Body:     c.Body,
+				Author:   model.Author{Name: c.Author.Name, AvatarURL: c.Author.AvatarURL},
+				Time:     c.Time,
 				URL:      c.URL,
 				Comments: model.Comments{Count: c.Comments.Count, URL: c.Comments.URL},
 			})
 		}
+		var rs []model.Release
+		for _, r := range rp.Presentation.Releases {
+			rs = append(rs, model.Release{
+				Tag:  r.Tag,
+				Name: r.Name,
+				Date: r.Date,
+				Body: r.Body,
+				URL:  r.URL,
+			})
+		}
+		var as []model.Advisory
+		for _, a := range rp.Presentation.Advisories {
+			as = append(as, model.Advisory{
+				ID:      a.ID,
+				Aliases: a.Aliases,
+				Summary: a.Summary,
+				URL:     a.URL,
+			})
+		}
 		repoPresentation := model.RepoPresentation{
-			RepoRoot:          rp.Repo.Root,
-			ImportPathPattern: rp.Repo.ImportPathPattern(),
-			LocalRevision:     rp.Repo.Local.Revision,
-			RemoteRevision:    rp.Repo.Remote.Revision,
-			HomeURL:           rp.Presentation.HomeURL,
-			ImageURL:          rp.Presentation.ImageURL,
-			Changes:           cs,
-			UpdateState:       model.UpdateState(rp.UpdateState),
-			UpdateSupported:   c.updater != nil,
+			RepoRoot:            rp.Repo.Root,
+			ImportPathPattern:   rp.Repo.ImportPathPattern(),
+			LocalRevision:       rp.Repo.Local.Revision,
+			RemoteRevision:      rp.Repo.Remote.Revision,
+			HomeURL:             rp.Presentation.HomeURL,
+			ImageURL:            rp.Presentation.ImageURL,
+			Changes:             cs,
+			TotalChanges:        rp.Presentation.TotalChanges,
+			Releases:            rs,
+			Advisories:          as,
+			IncompatibleChanges: rp.Presentation.IncompatibleChanges,
+			ImportedBy:          importedBy,
+			DirtyStatus:         rp.Repo.Local.Status,
+			Stale:               rp.Repo.Remote.LastKnown,
+			UpdateState:         model.UpdateState(rp.UpdateState),
+			UpdateSupported:     c.updater != nil,
+			IgnoreSupported:     c.config != nil,
+			PreviousRevision:    rp.PreviousRevision,
+			Verification:        modelVerification(rp.Verification),
+		}
+		if d := rp.Presentation.Diffstat; d != nil {
+			repoPresentation.Diffstat = modelDiffstat(rp.Repo.Root, c.pipeline.RepoPackages(rp.Repo.Root), d)
+		}
+		if u := rp.Repo.Remote.Upstream; u != nil {
+			repoPresentation.Fork = &model.Fork{
+				ForkURL:          rp.Repo.Local.RemoteURL,
+				UpstreamURL:      rp.Repo.Remote.RepoURL,
+				UpstreamBranch:   u.Branch,
+				UpstreamRevision: u.Revision,
+				Behind:           u.Behind,
+			}
+		}
+		if rp.Repo.Remote.Tracked {
+			repoPresentation.TrackedBranch = rp.Repo.Remote.Branch
+		}
+		if rp.Repo.Local.Diverged {
+			repoPresentation.Diverged = modelDivergence(rp.Analysis.Diverged)
+		}
+		if lc := rp.Presentation.LicenseChange; lc != nil {
+			repoPresentation.LicenseChange = &model.LicenseChange{From: lc.From, To: lc.To}
+		}
+		if c.updater != nil {
+			repoPresentation.UpdateRefused = updateRefusal(rp)
+			_, repoPresentation.RevertSupported = c.updater.(gps.Reverter)
+			_, repoPresentation.PlanSupported = c.updater.(gps.Planner)
 		}
 		if err := rp.Presentation.Error; err != nil {
 			repoPresentation.Error = err.Error()
@@ -52,3 +119,49 @@ func updatesHandler(w http.ResponseWriter, req *http.Request) error {
 	}
 	return nil
 }
+
+// modelDiffstat returns the model diffstat of d, with changed files grouped by
+// the Go package they belong to in repository with specified root.
+//
+// Go packages are the ones with import paths in packages, as found in the workspace,
+// and directories of changed .go files, which covers packages added by the update.
+// Other files, such as testdata, belong to the package in their closest parent directory,
+// or to the root of the repository if there's none.
+func modelDiffstat(root string, packages []string, d *presenter.Diffstat) *model.Diffstat {
+	isPackage := make(map[string]bool) // Set of import paths.
+	for _, importPath := range packages {
+		isPackage[importPath] = true
+	}
+	for _, f := range d.Files {
+		if path.Ext(f.Path) == ".go" {
+			isPackage[path.Join(root, path.Dir(f.Path))] = true
+		}
+	}
+
+	md := &model.Diffstat{Incomplete: d.Incomplete}
+	md.Additions, md.Deletions = d.Totals()
+	pkgs := make(map[string]*model.PackageDiffstat) // Import path -> package diffstat.
+	for _, f := range d.Files {
+		importPath := path.Join(root, path.Dir(f.Path))
+		for importPath != root && !isPackage[importPath] {
+			importPath = path.Dir(importPath)
+		}
+		pkg, ok := pkgs[importPath]
+		if !ok {
+			pkg = &model.PackageDiffstat{ImportPath: importPath}
+			pkgs[importPath] = pkg
+		}
+		pkg.Additions += f.Additions
+		pkg.Deletions += f.Deletions
+		pkg.Files = append(pkg.Files, model.FileChange{
+			Name:      strings.TrimPrefix(path.Join(root, f.Path), importPath+"/"),
+			Additions: f.Additions,
+			Deletions: f.Deletions,
+		})
+	}
+	for _, pkg := range pkgs {
+		md.Packages = append(md.Packages, *pkg)
+	}
+	sort.Slice(md.Packages, func(i, j int) bool { return md.Packages[i].ImportPath < md.Packages[j].ImportPath })
+	return md
+}
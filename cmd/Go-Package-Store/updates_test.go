@@ -0,0 +1,48 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/shurcooL/Go-Package-Store/frontend/model"
+	"github.com/shurcooL/Go-Package-Store/presenter"
+)
+
+func TestModelDiffstat(t *testing.T) {
+	d := &presenter.Diffstat{
+		Files: []presenter.FileChange{
+			{Path: "README.md", Additions: 1},
+			{Path: "docs/guide.md", Additions: 2},
+			{Path: "foo/foo.go", Additions: 3, Deletions: 1},
+			{Path: "foo/testdata/golden.txt", Deletions: 4},
+			{Path: "foo/testdata/input/a.txt", Deletions: 5},
+			{Path: "internal/bar/bar.s", Additions: 6},
+			{Path: "newpkg/new.go", Additions: 7},
+		},
+	}
+	got := modelDiffstat("example.com/repo", []string{"example.com/repo", "example.com/repo/foo", "example.com/repo/internal/bar"}, d)
+	want := &model.Diffstat{
+		Additions: 19,
+		Deletions: 10,
+		Packages: []model.PackageDiffstat{
+			{ImportPath: "example.com/repo", Additions: 3, Files: []model.FileChange{
+				{Name: "README.md", Additions: 1},
+				{Name: "docs/guide.md", Additions: 2},
+			}},
+			{ImportPath: "example.com/repo/foo", Additions: 3, Deletions: 10, Files: []model.FileChange{
+				{Name: "foo.go", Additions: 3, Deletions: 1},
+				{Name: "testdata/golden.txt", Deletions: 4},
+				{Name: "testdata/input/a.txt", Deletions: 5},
+			}},
+			{ImportPath: "example.com/repo/internal/bar", Additions: 6, Files: []model.FileChange{
+				{Name: "bar.s", Additions: 6},
+			}},
+			{ImportPath: "example.com/repo/newpkg", Additions: 7, Files: []model.FileChange{
+				{Name: "new.go", Additions: 7},
+			}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
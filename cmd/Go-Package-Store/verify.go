@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/shurcooL/Go-Package-Store"
+	"github.com/shurcooL/Go-Package-Store/frontend/model"
+	"github.com/shurcooL/Go-Package-Store/updater"
+	"github.com/shurcooL/Go-Package-Store/workspace"
+)
+
+// verifyCommand returns the command for verifying a completed update of rp,
+// as configured by -verify flag. It returns nil if there's nothing to verify.
+func verifyCommand(rp *workspace.RepoPresentation) *exec.Cmd {
+	var args []string
+	switch *verifyFlag {
+	case "build":
+		args = []string{"build"}
+	case "test":
+		args = []string{"test"}
+	default:
+		return nil
+	}
+
+	var dir string
+	switch u := c.updater.(type) {
+	case updater.Dep:
+		// Dependencies are vendored, so verify the entire project.
+		dir = u.Dir
+		args = append(args, "./...")
+	default:
+		// Verify packages in the workspace that import the repo.
+		c.pipeline.Packages.Lock()
+		importedBy := rp.Analysis.ImportedBy
+		c.pipeline.Packages.Unlock()
+		if len(importedBy) == 0 {
+			return nil
+		}
+		args = append(args, importedBy...)
+	}
+
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	// Packages are resolved from GOPATH workspaces and vendor directories.
+	cmd.Env = append(os.Environ(), "GO111MODULE=off")
+	return cmd
+}
+
+// verifyUpdate verifies a completed update of rp by building or testing
+// affected Go packages, as configured by -verify flag, writing the output to w.
+// If verification fails and rollback is true, the update is rolled back.
+// It returns nil if there's nothing to verify.
+func verifyUpdate(rp *workspace.RepoPresentation, w io.Writer, rollback bool) *workspace.Verification {
+	cmd := verifyCommand(rp)
+	if cmd == nil {
+		return nil
+	}
+	var output bytes.Buffer
+	cmd.Stdout = io.MultiWriter(w, &output)
+	cmd.Stderr = io.MultiWriter(w, &output)
+	fmt.Fprintln(w, strings.Join(cmd.Args, " "))
+	v := &workspace.Verification{Command: strings.Join(cmd.Args, " ")}
+	err := cmd.Run()
+	v.Output = output.String()
+	if err == nil {
+		return v
+	}
+	v.Error = err.Error()
+
+	if !rollback {
+		return v
+	}
+	reverter, ok := c.updater.(gps.Reverter)
+	if !ok {
+		fmt.Fprintf(w, "not rolling back, because updater %T doesn't support reverting\n", c.updater)
+		return v
+	}
+	fmt.Fprintln(w, "verification failed, rolling back")
+	err = reverter.Revert(rp.Repo, rp.Repo.Local.Revision, w)
+	if err != nil {
+		v.Error += fmt.Sprintf("; rolling back failed: %v", err)
+		return v
+	}
+	v.RolledBack = true
+	return v
+}
+
+// modelVerification returns the model verification of v. It returns nil if v is nil.
+func modelVerification(v *workspace.Verification) *model.Verification {
+	if v == nil {
+		return nil
+	}
+	return &model.Verification{
+		Command:    v.Command,
+		Error:      v.Error,
+		Output:     v.Output,
+		RolledBack: v.RolledBack,
+	}
+}
@@ -2,6 +2,8 @@ package component
 
 import (
 	"fmt"
+	"math"
+	"time"
 
 	"github.com/gopherjs/gopherjs/js"
 	"github.com/gopherjs/vecty"
@@ -16,24 +18,45 @@ import (
 // Header is a component that displays the header with tabs on top.
 type Header struct {
 	vecty.Core
+	Tab model.Tab `vecty:"prop"` // Currently selected tab.
 }
 
 // Render renders the component.
-func (*Header) Render() vecty.ComponentOrHTML {
+func (h *Header) Render() vecty.ComponentOrHTML {
 	return elem.Header(
 		vecty.Markup(style.Width("100%"), vecty.Style("text-align", "center")),
-		elem.Span(
-			vecty.Markup(vecty.Style("padding", string(style.Px(15))), vecty.Style("display", "inline-block")),
-			vecty.Text("Updates"),
+		h.tab(model.UpdatesTab, "Updates"),
+		h.tab(model.HistoryTab, "History"),
+	)
+}
+
+// tab returns a tab with text that switches to tab t when clicked,
+// unless it's already selected.
+func (h *Header) tab(t model.Tab, text string) *vecty.HTML {
+	if t == h.Tab {
+		return elem.Span(
+			vecty.Markup(vecty.Class("tab", "selected")),
+			vecty.Text(text),
+		)
+	}
+	return elem.Anchor(
+		vecty.Markup(
+			vecty.Class("tab"),
+			prop.Href("#"),
+			event.Click(func(e *vecty.Event) {
+				js.Global.Get("SwitchTab").Invoke(int(t))
+			}).PreventDefault(),
 		),
+		vecty.Text(text),
 	)
 }
 
-// updatesHeader combines checkingForUpdates, noUpdates and updatesHeading
+// updatesHeader combines checkingForUpdates, rateLimitBanner, noUpdates and updatesHeading
 // into one high level component.
 type updatesHeader struct {
 	Active          []*model.RepoPresentation
 	CheckingUpdates bool
+	RateLimit       *model.RateLimit
 }
 
 func (u updatesHeader) Render() []vecty.MarkupOrChild {
@@ -42,6 +65,10 @@ func (u updatesHeader) Render() []vecty.MarkupOrChild {
 	case u.CheckingUpdates:
 		// Show "Checking for updates..." while still checking.
 		ns = append(ns, heading(elem.Heading2, "Checking for updates..."))
+		if u.RateLimit != nil {
+			// Show why checking for updates is paused.
+			ns = append(ns, rateLimitBanner(u.RateLimit))
+		}
 	case !u.CheckingUpdates && len(u.Active) == 0:
 		// Show "No Updates Available" if we're done checking and there are no remaining updates.
 		ns = append(ns,
@@ -56,27 +83,35 @@ func (u updatesHeader) Render() []vecty.MarkupOrChild {
 		)
 	}
 	// Show number of updates available and Update All button.
-	available, updating, supported := u.status()
+	available, selected, updating, supported := u.status()
 	ns = append(ns, &updatesHeading{
 		Available:       available,
+		Selected:        selected,
 		Updating:        updating,
 		UpdateSupported: supported, // TODO: Fetch this value from backend once.
 	})
 	return ns
 }
 
-// status reports available, updating, supported updates in u.Active.
-func (u updatesHeader) status() (available uint, updating bool, supported bool) {
+// status reports available, selected, updating, supported updates in u.Active.
+// Diverged updates aren't counted as available, since they're not included in Update All.
+func (u updatesHeader) status() (available, selected uint, updating bool, supported bool) {
 	for _, rp := range u.Active {
 		switch rp.UpdateState {
 		case model.Available:
+			if rp.Diverged != nil {
+				continue
+			}
 			available++
+			if rp.Selected {
+				selected++
+			}
 			supported = rp.UpdateSupported
 		case model.Updating:
 			updating = true
 		}
 	}
-	return available, updating, supported
+	return available, selected, updating, supported
 }
 
 // updatesHeading is a heading that displays number of updates available,
@@ -84,6 +119,7 @@ func (u updatesHeader) status() (available uint, updating bool, supported bool)
 type updatesHeading struct {
 	vecty.Core
 	Available uint `vecty:"prop"`
+	Selected  uint `vecty:"prop"` // Number of available updates selected for updating in a batch.
 	Updating  bool `vecty:"prop"`
 
 	// TODO: Find a place for this.
@@ -109,6 +145,19 @@ func (u *updatesHeading) Render() vecty.ComponentOrHTML {
 		vecty.Text(status),
 		elem.Span(
 			vecty.Markup(vecty.Style("float", "right")),
+			vecty.If(u.UpdateSupported && u.Selected > 0,
+				elem.Anchor(
+					vecty.Markup(
+						vecty.Style("margin-right", string(style.Px(12))),
+						prop.Href("/api/update-batch"),
+						vecty.Property(atom.Title.String(), "Update selected repos together, rolling all of them back if any fails"),
+						event.Click(func(e *vecty.Event) {
+							js.Global.Get("UpdateSelected").Invoke()
+						}).PreventDefault(),
+					),
+					vecty.Text(fmt.Sprintf("Update Selected (%d)", u.Selected)),
+				),
+			),
 			u.updateAllButton(),
 		),
 	)
@@ -147,6 +196,19 @@ func (u *updatesHeading) updateAllButton() *vecty.HTML {
 	}
 }
 
+// rateLimitBanner is a banner that displays a remote API rate limit
+// that checking for updates is paused on, and when it's going to resume.
+func rateLimitBanner(rl *model.RateLimit) *vecty.HTML {
+	minutes := int(math.Ceil(time.Until(rl.Reset).Minutes()))
+	if minutes < 1 {
+		minutes = 1
+	}
+	return elem.Paragraph(
+		vecty.Markup(vecty.Class("rate-limit-banner")),
+		vecty.Text(fmt.Sprintf("%v rate limit reached, resuming in %dm", rl.Service, minutes)),
+	)
+}
+
 func heading(heading func(markup ...vecty.MarkupOrChild) *vecty.HTML, text string) *vecty.HTML {
 	return heading(
 		vecty.Markup(vecty.Style("text-align", "center")),
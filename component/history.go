@@ -0,0 +1,110 @@
+package component
+
+import (
+	"fmt"
+
+	"github.com/gopherjs/gopherjs/js"
+	"github.com/gopherjs/vecty"
+	"github.com/gopherjs/vecty/elem"
+	"github.com/gopherjs/vecty/event"
+	"github.com/gopherjs/vecty/prop"
+	"github.com/gopherjs/vecty/style"
+	"github.com/shurcooL/Go-Package-Store/frontend/model"
+)
+
+// HistoryContent returns the entire content of history tab.
+// entries are history log entries that match query, most recent first.
+func HistoryContent(entries []model.HistoryEntry, query string) []vecty.MarkupOrChild {
+	content := []vecty.MarkupOrChild{
+		vecty.Markup(vecty.Class("content")),
+		elem.Input(
+			vecty.Markup(
+				vecty.Class("history-search"),
+				prop.Type(prop.TypeSearch),
+				prop.Placeholder("Search history by repo, revision, updater or error"),
+				prop.Value(query),
+				event.Input(func(e *vecty.Event) {
+					js.Global.Get("SearchHistory").Invoke(e.Target.Get("value").String())
+				}),
+			),
+		),
+	}
+	if len(entries) == 0 {
+		content = append(content, heading(elem.Heading3, "No Updates Found"))
+	}
+	for _, e := range entries {
+		content = append(content, &HistoryEntry{HistoryEntry: e})
+	}
+	return []vecty.MarkupOrChild{
+		&Header{Tab: model.HistoryTab},
+		elem.Div(
+			vecty.Markup(vecty.Class("center-max-width")),
+			elem.Div(content...),
+		),
+	}
+}
+
+// HistoryEntry is a component that displays a single update in the history log.
+type HistoryEntry struct {
+	vecty.Core
+	model.HistoryEntry `vecty:"prop"`
+}
+
+// Render renders the component.
+func (h *HistoryEntry) Render() vecty.ComponentOrHTML {
+	return elem.Div(
+		vecty.Markup(vecty.Class("list-entry", "history-entry")),
+		elem.Div(
+			vecty.Markup(vecty.Class("list-entry-header")),
+			elem.Strong(vecty.Text(h.RepoRoot)),
+			vecty.If(h.Error != "",
+				elem.Span(
+					vecty.Markup(vecty.Class("history-failed")),
+					vecty.Text("failed"),
+				),
+			),
+			elem.Span(
+				vecty.Markup(style.Color("gray"), vecty.Style("float", "right")),
+				vecty.Text(h.Time.Local().Format("Jan 2, 2006 at 15:04")),
+			),
+		),
+		elem.Div(
+			vecty.Markup(vecty.Class("list-entry-body")),
+			elem.Div(
+				vecty.Text("Updated from "),
+				revision(h.LocalRevision),
+				vecty.Text(" to "),
+				revision(h.RemoteRevision),
+				vecty.Text(fmt.Sprintf(" by %v.", h.Updater)),
+			),
+			vecty.If(h.Error != "",
+				elem.Div(
+					vecty.Markup(vecty.Class("history-failed"), vecty.Style("margin-left", "0")),
+					vecty.Text(h.Error),
+				),
+			),
+			&Verification{
+				Verification: h.Verification,
+			},
+			vecty.If(h.Output != "",
+				elem.Details(
+					vecty.Markup(vecty.Class("history-output")),
+					elem.Summary(vecty.Text("Output")),
+					elem.Preformatted(vecty.Text(h.Output)),
+				),
+			),
+		),
+	)
+}
+
+// revision displays a revision, which may be unknown or not be a commit ID.
+func revision(rev string) vecty.ComponentOrHTML {
+	switch {
+	case rev == "":
+		return elem.Emphasis(vecty.Text("unknown revision"))
+	case len(rev) < 8:
+		return elem.Code(vecty.Markup(vecty.Class("commitID")), vecty.Text(rev))
+	default:
+		return &CommitID{ID: rev}
+	}
+}
@@ -3,6 +3,8 @@ package component
 import (
 	"bytes"
 	"fmt"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/gopherjs/gopherjs/js"
@@ -35,12 +37,143 @@ func (p *RepoPresentation) Render() vecty.ComponentOrHTML {
 		),
 		elem.Div(
 			vecty.Markup(vecty.Class("list-entry-header")),
+			vecty.If(p.batchSupported(),
+				elem.Input(
+					vecty.Markup(
+						vecty.Class("select-checkbox"),
+						prop.Type(prop.TypeCheckbox),
+						prop.Checked(p.Selected),
+						vecty.Property(atom.Title.String(), "Select for updating together with other selected repos"),
+						event.Change(func(e *vecty.Event) {
+							js.Global.Get("SelectRepository").Invoke(p.RepoRoot, e.Target.Get("checked").Bool())
+						}),
+					),
+				),
+			),
 			elem.Span(
 				vecty.Markup(vecty.Property(atom.Title.String(), p.ImportPathPattern)),
 				p.importPathPattern(),
 			),
+			vecty.If(p.TrackedBranch != "",
+				elem.Span(
+					vecty.Markup(
+						vecty.Class("branch-label"),
+						vecty.Property(atom.Title.String(), "Updates are tracked on upstream branch "+p.TrackedBranch+", rather than the default branch"),
+					),
+					elem.Span(
+						vecty.Markup(
+							vecty.Style("margin-right", string(style.Px(4))),
+							vecty.UnsafeHTML(octiconGitBranch),
+						),
+					),
+					vecty.Text(p.TrackedBranch),
+				),
+			),
+			vecty.If(!p.Stale.IsZero(),
+				elem.Span(
+					vecty.Markup(
+						vecty.Class("stale-label"),
+						vecty.Property(atom.Title.String(), "Remote state was fetched on "+p.Stale.Local().Format("Jan 2, 2006 at 15:04")+", because the remote couldn't be reached"),
+					),
+					vecty.Text("stale"),
+				),
+			),
+			vecty.If(len(p.Advisories) > 0,
+				elem.Span(
+					vecty.Markup(
+						vecty.Class("security-label"),
+						vecty.Property(atom.Title.String(), "Update fixes known vulnerabilities"),
+					),
+					elem.Span(
+						vecty.Markup(
+							vecty.Style("margin-right", string(style.Px(4))),
+							vecty.UnsafeHTML(octiconShield),
+						),
+					),
+					vecty.Text("security"),
+				),
+			),
+			vecty.If(p.LicenseChange != nil && !p.LicenseChange.Unknown(),
+				elem.Span(
+					vecty.Markup(
+						vecty.Class("license-label"),
+						vecty.Property(atom.Title.String(), "Update changes the license type"),
+					),
+					vecty.Text("license changed"),
+				),
+			),
+			vecty.If(p.LicenseChange != nil && p.LicenseChange.Unknown(),
+				elem.Span(
+					vecty.Markup(
+						vecty.Class("license-label"),
+						vecty.Property(atom.Title.String(), "License type of the update couldn't be determined"),
+					),
+					vecty.Text("license unknown"),
+				),
+			),
+			vecty.If(p.Fork != nil,
+				elem.Span(
+					vecty.Markup(
+						vecty.Class("fork-label"),
+						vecty.Property(atom.Title.String(), "Cloned from fork "+p.Fork.ForkURL),
+					),
+					vecty.Text("fork"),
+				),
+			),
+			vecty.If(p.Diverged != nil,
+				elem.Span(
+					vecty.Markup(
+						vecty.Class("diverged-label"),
+						vecty.Property(atom.Title.String(), "Local branch has commits that aren't on remote"),
+					),
+					vecty.Text("diverged"),
+				),
+			),
+			vecty.If(p.DirtyStatus != "",
+				elem.Span(
+					vecty.Markup(
+						vecty.Class("dirty-label"),
+						vecty.Property(atom.Title.String(), "Working tree has local changes"),
+					),
+					vecty.Text("dirty"),
+				),
+			),
+			vecty.If(len(p.IncompatibleChanges) > 0,
+				elem.Span(
+					vecty.Markup(
+						vecty.Class("incompatible-label"),
+						vecty.Property(atom.Title.String(), "Update has incompatible API changes"),
+					),
+					vecty.Text("incompatible"),
+				),
+			),
 			elem.Div(
 				vecty.Markup(vecty.Style("float", "right")),
+				vecty.If(p.IgnoreSupported && p.UpdateState == model.Available,
+					p.ignoreLink("Ignore", "Stop offering updates for this repo", "IgnoreRepository"),
+					p.ignoreLink("Snooze 7d", "Stop offering updates for this repo for 7 days", "SnoozeRepository"),
+				),
+				vecty.If(p.PlanSupported && p.UpdateState == model.Available,
+					p.previewLink(),
+				),
+				vecty.If(p.LocalRevision != "" && p.RemoteRevision != "",
+					elem.Anchor(
+						vecty.Markup(
+							vecty.Class("diff-link"),
+							prop.Href("/diff?root="+url.QueryEscape(p.RepoRoot)),
+							// TODO: Add rel="noopener", see https://dev.to/ben/the-targetblank-vulnerability-by-example.
+							vecty.Property(atom.Target.String(), "_blank"),
+							vecty.Property(atom.Title.String(), "View diff"),
+						),
+						elem.Span(
+							vecty.Markup(
+								vecty.Style("margin-right", string(style.Px(4))),
+								vecty.UnsafeHTML(octiconDiff),
+							),
+						),
+						vecty.Text("Diff"),
+					),
+				),
 				p.updateState(),
 			),
 		),
@@ -64,6 +197,13 @@ func (p *RepoPresentation) Render() vecty.ComponentOrHTML {
 	)
 }
 
+// batchSupported reports whether the update can be selected for updating in a batch.
+// Batches are rolled back on failure, so it requires reverting to be supported.
+func (p *RepoPresentation) batchSupported() bool {
+	return p.UpdateSupported && p.RevertSupported && p.UpdateRefused == "" && p.UpdateState == model.Available &&
+		p.Diverged == nil
+}
+
 // TODO: Turn this into a maybeLink, etc.
 func (p *RepoPresentation) importPathPattern() *vecty.HTML {
 	switch p.HomeURL {
@@ -81,6 +221,90 @@ func (p *RepoPresentation) importPathPattern() *vecty.HTML {
 	}
 }
 
+// previewLink returns a link that shows or hides the preview of what updating would do.
+func (p *RepoPresentation) previewLink() *vecty.HTML {
+	text, fn := "Preview", "PreviewRepository"
+	if p.Plan != nil {
+		text, fn = "Hide preview", "ClosePreview"
+	}
+	return elem.Anchor(
+		vecty.Markup(
+			vecty.Class("preview-link"),
+			prop.Href("/api/plan?root="+url.QueryEscape(p.RepoRoot)),
+			vecty.Property(atom.Title.String(), "Preview what updating would do"),
+			event.Click(func(e *vecty.Event) {
+				js.Global.Get(fn).Invoke(p.RepoRoot)
+			}).PreventDefault(),
+		),
+		vecty.Text(text),
+	)
+}
+
+// plan displays the preview of what updating would do,
+// with a button to confirm the update, if it's allowed.
+func (p *RepoPresentation) plan() *vecty.HTML {
+	var steps []string
+	steps = append(steps, p.Plan.Commands...)
+	for _, e := range p.Plan.Edits {
+		steps = append(steps, "# edit "+e)
+	}
+	confirm := p.UpdateSupported && p.UpdateRefused == "" && !p.Plan.DryRun
+	return elem.Div(
+		vecty.Markup(vecty.Class("plan")),
+		elem.Strong(vecty.Text("Updating would run:")),
+		elem.Preformatted(vecty.Text(strings.Join(steps, "\n"))),
+		vecty.If(p.Plan.DryRun,
+			elem.Span(
+				vecty.Markup(style.Color("gray")),
+				vecty.Text("Dry run mode is enabled, so nothing is updated."),
+			),
+		),
+		vecty.If(confirm,
+			elem.Button(
+				vecty.Markup(
+					event.Click(func(e *vecty.Event) {
+						js.Global.Get("UpdateRepository").Invoke(p.RepoRoot)
+					}),
+				),
+				vecty.Text("Confirm update"),
+			),
+		),
+	)
+}
+
+// ignoreLink returns a link that stops offering the update by invoking
+// the JavaScript function fn with the repo root.
+func (p *RepoPresentation) ignoreLink(text, title, fn string) *vecty.HTML {
+	return elem.Anchor(
+		vecty.Markup(
+			vecty.Class("ignore-link"),
+			prop.Href("/api/ignore"),
+			vecty.Property(atom.Title.String(), title),
+			event.Click(func(e *vecty.Event) {
+				js.Global.Get(fn).Invoke(p.RepoRoot)
+			}).PreventDefault(),
+		),
+		vecty.Text(text),
+	)
+}
+
+// updateText returns the text of the update action.
+// Diverged repos are updated by rebasing.
+func (p *RepoPresentation) updateText() string {
+	if p.Diverged != nil {
+		return "Rebase"
+	}
+	return "Update"
+}
+
+// updatingText returns the text of an update in progress.
+func (p *RepoPresentation) updatingText() string {
+	if p.Diverged != nil {
+		return "Rebasing..."
+	}
+	return "Updating..."
+}
+
 func (p *RepoPresentation) updateState() *vecty.HTML {
 	if !p.UpdateSupported {
 		return elem.Span(
@@ -88,7 +312,16 @@ func (p *RepoPresentation) updateState() *vecty.HTML {
 				style.Color("gray"), vecty.Style("cursor", "default"),
 				vecty.Property(atom.Title.String(), "Updating repos is not currently supported for this source of repos."),
 			),
-			vecty.Text("Update"),
+			vecty.Text(p.updateText()),
+		)
+	}
+	if p.UpdateRefused != "" && p.UpdateState == model.Available {
+		return elem.Span(
+			vecty.Markup(
+				style.Color("gray"), vecty.Style("cursor", "default"),
+				vecty.Property(atom.Title.String(), "Updating is refused: "+p.UpdateRefused+"."),
+			),
+			vecty.Text(p.updateText()),
 		)
 	}
 	switch p.UpdateState {
@@ -108,16 +341,27 @@ func (p *RepoPresentation) updateState() *vecty.HTML {
 
 				}).PreventDefault(),
 			),
-			vecty.Text("Update"),
+			vecty.Text(p.updateText()),
 		)
 	case model.Updating:
 		return elem.Span(
 			vecty.Markup(style.Color("gray"), vecty.Style("cursor", "default")),
-			vecty.Text("Updating..."),
+			vecty.Text(p.updatingText()),
 		)
 	case model.Updated:
-		// TODO.
-		return nil
+		if !p.RevertSupported || p.PreviousRevision == "" {
+			return nil
+		}
+		return elem.Anchor(
+			vecty.Markup(
+				prop.Href("/api/revert"),
+				vecty.Property(atom.Title.String(), "Revert to previous revision "+p.PreviousRevision),
+				event.Click(func(e *vecty.Event) {
+					js.Global.Get("RevertRepository").Invoke(p.RepoRoot)
+				}).PreventDefault(),
+			),
+			vecty.Text("Revert"),
+		)
 	default:
 		panic("unreachable")
 	}
@@ -126,9 +370,57 @@ func (p *RepoPresentation) updateState() *vecty.HTML {
 func (p *RepoPresentation) presentationChangesAndError() []vecty.MarkupOrChild {
 	return []vecty.MarkupOrChild{
 		vecty.Markup(vecty.Style("word-break", "break-word")),
+		vecty.If(!p.Stale.IsZero(),
+			p.stale(),
+		),
+		&Advisories{
+			Advisories: p.Advisories,
+		},
+		vecty.If(p.LicenseChange != nil,
+			p.licenseChange(),
+		),
+		vecty.If(p.Fork != nil,
+			p.fork(),
+		),
+		vecty.If(p.Diverged != nil && p.UpdateState == model.Available,
+			p.divergence(),
+		),
+		vecty.If(p.DirtyStatus != "" && p.UpdateState == model.Available,
+			p.dirtyStatus(),
+		),
+		vecty.If(p.Plan != nil && p.UpdateState == model.Available,
+			p.plan(),
+		),
+		vecty.If(p.StashConflict != nil,
+			p.stashConflict(),
+		),
+		&Verification{
+			Verification: p.Verification,
+		},
+		&ImportedBy{
+			ImportPaths: p.ImportedBy,
+		},
+		&Diffstat{
+			Diffstat: p.Diffstat,
+		},
+		&IncompatibleChanges{
+			Changes: p.IncompatibleChanges,
+		},
+		&Releases{
+			Releases: p.Releases,
+		},
 		&PresentationChanges{
 			RepoPresentation: p.RepoPresentation,
 		},
+		vecty.If(p.UpdateError != "",
+			elem.Paragraph(
+				// Preserve line breaks, e.g., of lists of conflicting files.
+				vecty.Markup(vecty.Class("presentation-error"), vecty.Style("white-space", "pre-wrap")),
+				elem.Strong(vecty.Text("Update failed:")),
+				vecty.Text(" "),
+				vecty.Text(p.UpdateError),
+			),
+		),
 		vecty.If(p.Error != "",
 			elem.Paragraph(
 				vecty.Markup(vecty.Class("presentation-error")),
@@ -140,13 +432,175 @@ func (p *RepoPresentation) presentationChangesAndError() []vecty.MarkupOrChild {
 	}
 }
 
+func (p *RepoPresentation) stale() *vecty.HTML {
+	return elem.Div(
+		vecty.Markup(vecty.Class("stale")),
+		elem.Span(
+			vecty.Markup(
+				vecty.Style("margin-right", string(style.Px(4))),
+				vecty.UnsafeHTML(octiconClock),
+			),
+		),
+		elem.Strong(vecty.Text("Remote couldn't be reached")),
+		vecty.Text(", so this update is based on the last-known remote state, fetched on "+p.Stale.Local().Format("Jan 2, 2006 at 15:04")+"."),
+	)
+}
+
+func (p *RepoPresentation) licenseChange() *vecty.HTML {
+	if p.LicenseChange.Unknown() {
+		return elem.Div(
+			vecty.Markup(vecty.Class("license-change")),
+			elem.Span(
+				vecty.Markup(
+					vecty.Style("margin-right", string(style.Px(4))),
+					vecty.UnsafeHTML(octiconLaw),
+				),
+			),
+			elem.Strong(vecty.Text("License type couldn't be determined")),
+			vecty.Text(" (from "),
+			elem.Strong(vecty.Text(p.LicenseChange.From)),
+			vecty.Text(" to "),
+			elem.Strong(vecty.Text(p.LicenseChange.To)),
+			vecty.Text(")"),
+		)
+	}
+	return elem.Div(
+		vecty.Markup(vecty.Class("license-change")),
+		elem.Span(
+			vecty.Markup(
+				vecty.Style("margin-right", string(style.Px(4))),
+				vecty.UnsafeHTML(octiconLaw),
+			),
+		),
+		elem.Strong(vecty.Text("License changed")),
+		vecty.Text(" from "),
+		elem.Strong(vecty.Text(p.LicenseChange.From)),
+		vecty.Text(" to "),
+		elem.Strong(vecty.Text(p.LicenseChange.To)),
+	)
+}
+
+func (p *RepoPresentation) fork() *vecty.HTML {
+	var behind vecty.MarkupOrChild
+	switch p.Fork.Behind {
+	case 0:
+		behind = vecty.Text("Fork is up to date with upstream ")
+	case 1:
+		behind = vecty.Text("Fork is 1 commit behind upstream ")
+	default:
+		behind = vecty.Text(fmt.Sprintf("Fork is %d commits behind upstream ", p.Fork.Behind))
+	}
+	return elem.Div(
+		vecty.Markup(vecty.Class("fork")),
+		elem.Span(
+			vecty.Markup(
+				vecty.Style("margin-right", string(style.Px(4))),
+				vecty.UnsafeHTML(octiconRepoForked),
+			),
+		),
+		elem.Strong(behind),
+		elem.Code(vecty.Text(p.Fork.UpstreamURL)),
+		vecty.Text(" branch "),
+		elem.Code(vecty.Text(p.Fork.UpstreamBranch)),
+		vecty.Text(" at "),
+		revision(p.Fork.UpstreamRevision),
+		vecty.Text(". Updates are pulled from fork "),
+		elem.Code(vecty.Text(p.Fork.ForkURL)),
+		vecty.Text("."),
+	)
+}
+
+func (p *RepoPresentation) divergence() *vecty.HTML {
+	var commits []vecty.MarkupOrChild
+	for _, c := range p.Diverged.LocalCommits {
+		commits = append(commits, elem.ListItem(
+			revision(c.Revision),
+			vecty.Text(" "),
+			vecty.Text(c.Message),
+		))
+	}
+	return elem.Div(
+		vecty.Markup(vecty.Class("divergence")),
+		elem.Strong(vecty.Text("Local branch has diverged")),
+		vecty.Text(" and updating rebases local commits onto the new upstream commits below."),
+		vecty.If(len(commits) > 0,
+			elem.UnorderedList(commits...),
+		),
+	)
+}
+
+func (p *RepoPresentation) dirtyStatus() *vecty.HTML {
+	return elem.Div(
+		vecty.Markup(vecty.Class("dirty-status")),
+		elem.Span(
+			vecty.Markup(
+				vecty.Style("margin-right", string(style.Px(4))),
+				vecty.UnsafeHTML(octiconAlert),
+			),
+		),
+		elem.Strong(vecty.Text("Working tree has local changes")),
+		elem.Preformatted(vecty.Text(p.DirtyStatus)),
+	)
+}
+
+func (p *RepoPresentation) stashConflict() *vecty.HTML {
+	return elem.Div(
+		vecty.Markup(vecty.Class("dirty-status")),
+		elem.Span(
+			vecty.Markup(
+				vecty.Style("margin-right", string(style.Px(4))),
+				vecty.UnsafeHTML(octiconAlert),
+			),
+		),
+		elem.Strong(vecty.Text("Local changes couldn't be re-applied, they're kept in the stash")),
+		vecty.If(len(p.StashConflict.Files) > 0,
+			elem.Preformatted(vecty.Text("Conflicts in:\n"+strings.Join(p.StashConflict.Files, "\n"))),
+		),
+		vecty.If(len(p.StashConflict.Files) == 0,
+			elem.Preformatted(vecty.Text(p.StashConflict.Error)),
+		),
+	)
+}
+
+// Verification is a component that displays the result of verifying a completed update.
+type Verification struct {
+	vecty.Core
+	*model.Verification `vecty:"prop"`
+}
+
+// Render renders the component.
+func (v *Verification) Render() vecty.ComponentOrHTML {
+	if v.Verification == nil {
+		return nil
+	}
+	status := "Verified with "
+	class := "verification"
+	if v.Error != "" {
+		status = "Verification failed with "
+		class = "verification verification-failed"
+	}
+	return elem.Div(
+		vecty.Markup(vecty.Class(strings.Fields(class)...)),
+		elem.Strong(vecty.Text(status)),
+		elem.Code(vecty.Text(v.Command)),
+		vecty.If(v.Error != "", vecty.Text(": "+v.Error)),
+		vecty.If(v.RolledBack, vecty.Text(". The update was rolled back.")),
+		vecty.If(v.Output != "",
+			elem.Details(
+				elem.Summary(vecty.Text("Output")),
+				elem.Preformatted(vecty.Text(v.Output)),
+			),
+		),
+	)
+}
+
 // PresentationChanges is a component containing changes within an update.
 type PresentationChanges struct {
 	vecty.Core
 	//Changes        []*Change
 	//LocalRevision  string // Only needed if len(Changes) == 0.
 	//RemoteRevision string // Only needed if len(Changes) == 0.
-	*model.RepoPresentation `vecty:"prop"` // Only uses Changes, and if len(Changes) == 0, then LocalRevision and RemoteRevision.
+	*model.RepoPresentation `vecty:"prop"` // Only uses Changes and TotalChanges, and if len(Changes) == 0, then LocalRevision and RemoteRevision.
 }
 
 // Restore is called when the component should restore itself against a
@@ -188,6 +642,12 @@ func (p *PresentationChanges) Render() vecty.ComponentOrHTML {
 				Change: &p.Changes[i],
 			})
 		}
+		if p.TotalChanges > len(p.Changes) {
+			ns = append(ns, elem.ListItem(
+				vecty.Markup(style.Color("gray"), vecty.Style("list-style-type", "none")),
+				vecty.Text(fmt.Sprintf("%d changes (showing %d)", p.TotalChanges, len(p.Changes))),
+			))
+		}
 		return elem.UnorderedList(ns...)
 	case 0:
 		return elem.Div(
@@ -205,16 +665,307 @@ func (p *PresentationChanges) Render() vecty.ComponentOrHTML {
 	}
 }
 
+// Advisories is a component that lists known vulnerabilities fixed by an update.
+type Advisories struct {
+	vecty.Core
+	Advisories []model.Advisory `vecty:"prop"`
+}
+
+// Render renders the component.
+func (a *Advisories) Render() vecty.ComponentOrHTML {
+	if len(a.Advisories) == 0 {
+		return nil
+	}
+	var items []vecty.MarkupOrChild
+	for _, adv := range a.Advisories {
+		id := vecty.ComponentOrHTML(elem.Strong(vecty.Text(adv.ID)))
+		if adv.URL != "" {
+			id = elem.Anchor(
+				vecty.Markup(
+					prop.Href(adv.URL),
+					// TODO: Add rel="noopener", see https://dev.to/ben/the-targetblank-vulnerability-by-example.
+					vecty.Property(atom.Target.String(), "_blank"),
+				),
+				elem.Strong(vecty.Text(adv.ID)),
+			)
+		}
+		items = append(items, elem.ListItem(
+			id,
+			vecty.If(len(adv.Aliases) > 0,
+				elem.Span(
+					vecty.Markup(style.Color("gray")),
+					vecty.Text(" ("+strings.Join(adv.Aliases, ", ")+")"),
+				),
+			),
+			vecty.If(adv.Summary != "",
+				vecty.Text(": "+adv.Summary),
+			),
+		))
+	}
+	return elem.Div(
+		vecty.Markup(vecty.Class("advisories")),
+		elem.Div(
+			elem.Span(
+				vecty.Markup(
+					vecty.Style("margin-right", string(style.Px(4))),
+					vecty.UnsafeHTML(octiconShield),
+				),
+			),
+			elem.Strong(vecty.Text("Fixes known vulnerabilities")),
+		),
+		elem.UnorderedList(items...),
+	)
+}
+
+// Diffstat is a component that summarizes changes to files within an update.
+// Expanding it shows changed files grouped by Go package.
+type Diffstat struct {
+	vecty.Core
+	*model.Diffstat `vecty:"prop"`
+}
+
+// Render renders the component.
+func (d *Diffstat) Render() vecty.ComponentOrHTML {
+	if d.Diffstat == nil {
+		return nil
+	}
+	files := 0
+	var pkgs []vecty.MarkupOrChild
+	for _, pkg := range d.Packages {
+		files += len(pkg.Files)
+		var fs []vecty.MarkupOrChild
+		for _, f := range pkg.Files {
+			fs = append(fs, elem.ListItem(
+				vecty.Text(f.Name+" "),
+				lineCounts(f.Additions, f.Deletions),
+			))
+		}
+		pkgs = append(pkgs, elem.ListItem(
+			elem.Strong(vecty.Text(pkg.ImportPath+" ")),
+			lineCounts(pkg.Additions, pkg.Deletions),
+			diffstatBar(pkg.Additions, pkg.Deletions),
+			elem.UnorderedList(fs...),
+		))
+	}
+	summary := fmt.Sprintf("%d files changed", files)
+	if files == 1 {
+		summary = "1 file changed"
+	}
+	if d.Incomplete {
+		summary = "at least " + summary
+	}
+	return elem.Details(
+		vecty.Markup(vecty.Class("diffstat")),
+		elem.Summary(
+			vecty.Text(summary+" "),
+			lineCounts(d.Additions, d.Deletions),
+			diffstatBar(d.Additions, d.Deletions),
+		),
+		elem.UnorderedList(pkgs...),
+	)
+}
+
+// lineCounts renders counts of added and deleted lines.
+func lineCounts(additions, deletions int) *vecty.HTML {
+	return elem.Span(
+		elem.Span(vecty.Markup(vecty.Class("diffstat-added")), vecty.Text(fmt.Sprintf("+%d", additions))),
+		vecty.Text(" "),
+		elem.Span(vecty.Markup(vecty.Class("diffstat-deleted")), vecty.Text(fmt.Sprintf("−%d", deletions))),
+	)
+}
+
+// diffstatBlocks is the number of blocks in a diffstat bar.
+const diffstatBlocks = 5
+
+// diffstatBar renders a bar of blocks showing the proportion of added and deleted lines.
+func diffstatBar(additions, deletions int) *vecty.HTML {
+	added, deleted := 0, 0
+	if total := additions + deletions; total > 0 {
+		added = (additions*diffstatBlocks + total/2) / total
+		deleted = diffstatBlocks - added
+		if total < diffstatBlocks {
+			// Don't exaggerate small changes.
+			added, deleted = additions, deletions
+		}
+	}
+	blocks := []vecty.MarkupOrChild{vecty.Markup(vecty.Class("diffstat-bar"))}
+	for i := 0; i < diffstatBlocks; i++ {
+		class := "diffstat-block-neutral"
+		switch {
+		case i < added:
+			class = "diffstat-block-added"
+		case i < added+deleted:
+			class = "diffstat-block-deleted"
+		}
+		blocks = append(blocks, elem.Span(vecty.Markup(vecty.Class(class))))
+	}
+	return elem.Span(blocks...)
+}
+
+// ImportedBy is a component that lists packages in the workspace
+// that import the repository being updated.
+type ImportedBy struct {
+	vecty.Core
+	ImportPaths []string `vecty:"prop"`
+}
+
+// Render renders the component.
+func (i *ImportedBy) Render() vecty.ComponentOrHTML {
+	if len(i.ImportPaths) == 0 {
+		return nil
+	}
+	summary := "imported by 1 package in your workspace"
+	if len(i.ImportPaths) != 1 {
+		summary = fmt.Sprintf("imported by %d packages in your workspace", len(i.ImportPaths))
+	}
+	var items []vecty.MarkupOrChild
+	for _, importPath := range i.ImportPaths {
+		items = append(items, elem.ListItem(vecty.Text(importPath)))
+	}
+	return elem.Details(
+		vecty.Markup(vecty.Class("imported-by")),
+		elem.Summary(vecty.Text(summary)),
+		elem.UnorderedList(items...),
+	)
+}
+
+// IncompatibleChanges is a component that highlights incompatible
+// changes to exported APIs within an update.
+type IncompatibleChanges struct {
+	vecty.Core
+	Changes []string `vecty:"prop"`
+}
+
+// Render renders the component.
+func (i *IncompatibleChanges) Render() vecty.ComponentOrHTML {
+	if len(i.Changes) == 0 {
+		return nil
+	}
+	ns := []vecty.MarkupOrChild{
+		vecty.Markup(vecty.Class("incompatible-changes")),
+		elem.Div(
+			elem.Span(
+				vecty.Markup(
+					vecty.Style("margin-right", string(style.Px(4))),
+					vecty.UnsafeHTML(octiconAlert),
+				),
+			),
+			elem.Strong(vecty.Text(fmt.Sprintf("Incompatible API changes (%d)", len(i.Changes)))),
+		),
+	}
+	var items []vecty.MarkupOrChild
+	for _, c := range i.Changes {
+		items = append(items, elem.ListItem(elem.Code(vecty.Text(c))))
+	}
+	ns = append(ns, elem.UnorderedList(items...))
+	return elem.Div(ns...)
+}
+
+// Releases is a component containing releases and tags crossed by an update.
+type Releases struct {
+	vecty.Core
+	Releases []model.Release `vecty:"prop"`
+}
+
+// Render renders the component.
+func (r *Releases) Render() vecty.ComponentOrHTML {
+	if len(r.Releases) == 0 {
+		return nil
+	}
+	ns := []vecty.MarkupOrChild{
+		vecty.Markup(vecty.Class("releases-list")),
+	}
+	for i := range r.Releases {
+		ns = append(ns, &Release{
+			Release: &r.Releases[i],
+		})
+	}
+	return elem.UnorderedList(ns...)
+}
+
+// Release is a component for a single release or tag.
+// Release notes, if any, are displayed when it's expanded.
+type Release struct {
+	vecty.Core
+	*model.Release `vecty:"prop"`
+}
+
+// Render renders the component.
+func (r *Release) Render() vecty.ComponentOrHTML {
+	summary := []vecty.MarkupOrChild{
+		elem.Span(
+			vecty.Markup(
+				style.Color("gray"), vecty.Style("margin-right", string(style.Px(4))),
+				vecty.UnsafeHTML(octiconTag),
+			),
+		),
+		elem.Strong(vecty.Text(r.Name)),
+		vecty.If(!r.Date.IsZero(),
+			elem.Span(
+				vecty.Markup(style.Color("gray")),
+				vecty.Text(" released "+r.Date.Format("Jan 2, 2006")),
+			),
+		),
+		vecty.If(r.URL != "",
+			elem.Span(
+				vecty.Markup(vecty.Class("highlight-on-hover")),
+				elem.Anchor(
+					vecty.Markup(
+						prop.Href(r.URL),
+						// TODO: Add rel="noopener", see https://dev.to/ben/the-targetblank-vulnerability-by-example.
+						vecty.Property(atom.Target.String(), "_blank"),
+						vecty.Property(atom.Title.String(), "Release"),
+						vecty.UnsafeHTML(octiconLinkExternal),
+					),
+				),
+			),
+		),
+	}
+	if r.Body == "" {
+		return elem.ListItem(summary...)
+	}
+	return elem.ListItem(
+		elem.Details(
+			elem.Summary(summary...),
+			elem.Div(
+				vecty.Markup(
+					vecty.Class("release-body"),
+					vecty.UnsafeHTML(r.Body), // Sanitized by the host that rendered it.
+				),
+			),
+		),
+	)
+}
+
 // Change is a component for a single commit message.
+// Clicking on the message expands it to show the full message, author and time.
 type Change struct {
 	vecty.Core
 	*model.Change `vecty:"prop"`
+
+	expanded bool
 }
 
 // Render renders the component.
 func (c *Change) Render() vecty.ComponentOrHTML {
 	return elem.ListItem(
-		vecty.Text(c.Message),
+		elem.Span(
+			vecty.Markup(
+				vecty.MarkupIf(c.expandable(),
+					vecty.Class("expandable"),
+					vecty.Property(atom.Title.String(), "Show details"),
+					event.Click(func(*vecty.Event) {
+						c.expanded = !c.expanded
+						vecty.Rerender(c)
+					}),
+				),
+			),
+			vecty.Text(c.Message),
+			vecty.If(c.Body != "" && !c.expanded, elem.Span(
+				vecty.Markup(vecty.Class("expand-hint")),
+				vecty.Text("…"),
+			)),
+		),
 		elem.Span(
 			vecty.Markup(vecty.Class("highlight-on-hover")),
 			elem.Anchor(
@@ -231,6 +982,38 @@ func (c *Change) Render() vecty.ComponentOrHTML {
 			vecty.Markup(vecty.Style("float", "right"), vecty.Style("margin-right", string(style.Px(6)))),
 			&Comments{Comments: &c.Comments},
 		),
+		vecty.If(c.expanded, c.details()),
+	)
+}
+
+// expandable reports whether c has more details to show than its message.
+func (c *Change) expandable() bool {
+	return c.Body != "" || c.Author.Name != "" || !c.Time.IsZero()
+}
+
+// details renders the full message, author and time of the change.
+func (c *Change) details() *vecty.HTML {
+	return elem.Div(
+		vecty.Markup(vecty.Class("change-details")),
+		elem.Div(
+			vecty.If(c.Author.AvatarURL != "", elem.Image(
+				vecty.Markup(
+					vecty.Class("change-author-avatar"),
+					vecty.Property(atom.Src.String(), c.Author.AvatarURL),
+					vecty.Property(atom.Width.String(), "16"),
+					vecty.Property(atom.Height.String(), "16"),
+				),
+			)),
+			vecty.If(c.Author.Name != "", elem.Strong(vecty.Text(c.Author.Name))),
+			vecty.If(!c.Time.IsZero(), elem.Span(
+				vecty.Markup(style.Color("gray")),
+				vecty.Text(" authored on "+c.Time.Format("Jan 2, 2006 at 15:04")),
+			)),
+		),
+		vecty.If(c.Body != "", elem.Div(
+			vecty.Markup(vecty.Class("change-body")),
+			vecty.Text(c.Body),
+		)),
 	)
 }
 
@@ -285,8 +1068,17 @@ func (c *CommitID) Render() vecty.ComponentOrHTML {
 func (c *CommitID) commitID() string { return c.ID[:8] }
 
 var (
-	octiconGitCommit = render(octicon.GitCommit)
-	octiconComment   = render(octicon.Comment)
+	octiconGitCommit    = render(octicon.GitCommit)
+	octiconComment      = render(octicon.Comment)
+	octiconTag          = render(octicon.Tag)
+	octiconLinkExternal = render(octicon.LinkExternal)
+	octiconAlert        = render(octicon.Alert)
+	octiconShield       = render(octicon.Shield)
+	octiconLaw          = render(octicon.Law)
+	octiconDiff         = render(octicon.Diff)
+	octiconGitBranch    = render(octicon.GitBranch)
+	octiconRepoForked   = render(octicon.RepoForked)
+	octiconClock        = render(octicon.Clock)
 )
 
 func render(icon func() *html.Node) string {
@@ -7,19 +7,20 @@ import (
 )
 
 // UpdatesContent returns the entire content of updates tab.
-func UpdatesContent(active, history []*model.RepoPresentation, checkingUpdates bool) []vecty.MarkupOrChild {
+// rateLimit is the remote API rate limit that checking for updates is paused on, if any.
+func UpdatesContent(active, history []*model.RepoPresentation, checkingUpdates bool, rateLimit *model.RateLimit) []vecty.MarkupOrChild {
 	return []vecty.MarkupOrChild{
-		&Header{},
+		&Header{Tab: model.UpdatesTab},
 		elem.Div(
 			vecty.Markup(vecty.Class("center-max-width")),
 			elem.Div(
-				updatesContent(active, history, checkingUpdates)...,
+				updatesContent(active, history, checkingUpdates, rateLimit)...,
 			),
 		),
 	}
 }
 
-func updatesContent(active, history []*model.RepoPresentation, checkingUpdates bool) []vecty.MarkupOrChild {
+func updatesContent(active, history []*model.RepoPresentation, checkingUpdates bool, rateLimit *model.RateLimit) []vecty.MarkupOrChild {
 	var content = []vecty.MarkupOrChild{
 		vecty.Markup(vecty.Class("content")),
 	}
@@ -29,16 +30,36 @@ func updatesContent(active, history []*model.RepoPresentation, checkingUpdates b
 		updatesHeader{
 			Active:          active,
 			CheckingUpdates: checkingUpdates,
+			RateLimit:       rateLimit,
 		}.Render()...,
 	)
 
-	// Active updates.
+	// Active updates, except for diverged ones.
+	var diverged []*model.RepoPresentation
 	for _, rp := range active {
+		if rp.Diverged != nil {
+			diverged = append(diverged, rp)
+			continue
+		}
 		content = append(content, &RepoPresentation{
 			RepoPresentation: rp,
 		})
 	}
 
+	// Diverged updates with "Diverged From Remote" heading, if any.
+	if len(diverged) > 0 {
+		content = append(content, elem.Heading3(
+			vecty.Markup(vecty.Style("text-align", "center"), vecty.Style("margin-top", "40px")),
+			vecty.Text("Diverged From Remote"),
+		))
+
+		for _, rp := range diverged {
+			content = append(content, &RepoPresentation{
+				RepoPresentation: rp,
+			})
+		}
+	}
+
 	// History with "Recently Installed Updates" heading, if any.
 	if len(history) > 0 {
 		content = append(content, elem.Heading3(
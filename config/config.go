@@ -0,0 +1,208 @@
+// Package config provides the user configuration of Go Package Store,
+// which controls what updates are offered.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config is the user configuration.
+type Config struct {
+	// Ignore are import path patterns of repos to never offer updates for,
+	// e.g., "github.com/foo/bar" or "github.com/foo/...".
+	Ignore []string `json:",omitempty"`
+
+	// Pins hold repos at a revision.
+	Pins []Pin `json:",omitempty"`
+
+	// Snoozes postpone offering updates for repos.
+	Snoozes []Snooze `json:",omitempty"`
+
+	// Mirrors map URL prefixes of mirrors to URL prefixes of the repositories they mirror,
+	// so that repos cloned from mirrors are recognized.
+	Mirrors []Mirror `json:",omitempty"`
+}
+
+// Pin holds a repo at a revision. Updates aren't offered for the repo
+// while its local revision is the pinned revision.
+type Pin struct {
+	Root     string // Import path corresponding to the root of the repository.
+	Revision string // Pinned revision.
+}
+
+// Snooze postpones offering updates for a repo until a time.
+type Snooze struct {
+	Root  string // Import path corresponding to the root of the repository.
+	Until time.Time
+}
+
+// Mirror maps URLs of a mirror to URLs of the repositories it mirrors.
+type Mirror struct {
+	URL       string // URL prefix of the mirror, e.g., "https://mirror.example.com/github/".
+	Canonical string // URL prefix it mirrors, e.g., "https://github.com/".
+}
+
+// Skip returns the reason why an update of repo with specified root and local revision
+// shouldn't be offered at time now, or empty string if it should be offered.
+// Ignore patterns are compiled on every call, so File should be preferred
+// for checking many repos, since it compiles them once.
+func (c Config) Skip(root, localRevision string, now time.Time) string {
+	return c.skip(compilePatterns(c.Ignore), root, localRevision, now)
+}
+
+// skip is like Skip, with c.Ignore patterns already compiled into ignore.
+func (c Config) skip(ignore []*regexp.Regexp, root, localRevision string, now time.Time) string {
+	for i, re := range ignore {
+		if re.MatchString(root) {
+			return fmt.Sprintf("it's ignored by pattern %q in config", c.Ignore[i])
+		}
+	}
+	for _, p := range c.Pins {
+		if p.Root == root && p.Revision == localRevision {
+			return fmt.Sprintf("it's pinned at revision %v in config", p.Revision)
+		}
+	}
+	for _, s := range c.Snoozes {
+		if s.Root == root && now.Before(s.Until) {
+			return fmt.Sprintf("it's snoozed until %v in config", s.Until.Format(time.RFC1123))
+		}
+	}
+	return ""
+}
+
+// compilePatterns compiles import path patterns into regexps that match
+// the import paths they match, in the same order.
+// Patterns may contain "..." wildcards, which match any string.
+// As a special case, a trailing "/..." also matches the import path without it,
+// like patterns of the go command.
+func compilePatterns(patterns []string) []*regexp.Regexp {
+	var res []*regexp.Regexp
+	for _, pattern := range patterns {
+		re := regexp.QuoteMeta(pattern)
+		re = strings.Replace(re, `\.\.\.`, `.*`, -1)
+		if strings.HasSuffix(re, `/.*`) {
+			re = strings.TrimSuffix(re, `/.*`) + `(/.*)?`
+		}
+		res = append(res, regexp.MustCompile(`^`+re+`$`))
+	}
+	return res
+}
+
+// Dir returns the directory of Go Package Store in the user configuration directory,
+// where the configuration file and other persistent files are stored.
+func Dir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "github.com", "shurcooL", "Go-Package-Store"), nil
+}
+
+// Path returns the path of the configuration file in the user configuration directory.
+func Path() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.json"), nil
+}
+
+// File is a configuration stored in a file.
+// It's safe for concurrent use.
+type File struct {
+	path string
+
+	mu     sync.Mutex
+	config Config
+	ignore []*regexp.Regexp // Compiled config.Ignore patterns.
+}
+
+// Open opens configuration file at path. A missing file is an empty configuration.
+func Open(path string) (*File, error) {
+	f := &File{path: path}
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return f, nil
+	} else if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(b, &f.config)
+	if err != nil {
+		return nil, fmt.Errorf("parsing config file %v: %v", path, err)
+	}
+	f.ignore = compilePatterns(f.config.Ignore)
+	return f, nil
+}
+
+// Skip returns the reason why an update of repo with specified root and local revision
+// shouldn't be offered now, or empty string if it should be offered.
+func (f *File) Skip(root, localRevision string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.config.skip(f.ignore, root, localRevision, time.Now())
+}
+
+// Mirrors returns the configured mirrors.
+func (f *File) Mirrors() []Mirror {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Mirror(nil), f.config.Mirrors...)
+}
+
+// Ignore adds repo with specified root to the ignore list, and saves the file.
+func (f *File) Ignore(root string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, pattern := range f.config.Ignore {
+		if pattern == root {
+			return nil
+		}
+	}
+	f.config.Ignore = append(f.config.Ignore, root)
+	f.ignore = append(f.ignore, compilePatterns([]string{root})...)
+	return f.save()
+}
+
+// Snooze postpones offering updates for repo with specified root for duration d,
+// and saves the file. Expired snoozes are removed.
+func (f *File) Snooze(root string, d time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := time.Now()
+	var snoozes []Snooze
+	for _, s := range f.config.Snoozes {
+		if s.Root == root || !now.Before(s.Until) {
+			continue
+		}
+		snoozes = append(snoozes, s)
+	}
+	f.config.Snoozes = append(snoozes, Snooze{Root: root, Until: now.Add(d).Round(time.Second)})
+	return f.save()
+}
+
+// save writes the configuration to the file, replacing it atomically.
+// f.mu must be held.
+func (f *File) save() error {
+	b, err := json.MarshalIndent(f.config, "", "\t")
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(f.path), 0700)
+	if err != nil {
+		return err
+	}
+	tmp := f.path + ".tmp"
+	err = ioutil.WriteFile(tmp, append(b, '\n'), 0600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, f.path)
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSkip(t *testing.T) {
+	now := time.Date(2017, time.March, 1, 12, 0, 0, 0, time.UTC)
+	c := Config{
+		Ignore: []string{"github.com/foo/bar", "golang.org/x/..."},
+		Pins:   []Pin{{Root: "github.com/pinned/repo", Revision: "abc"}},
+		Snoozes: []Snooze{
+			{Root: "github.com/snoozed/repo", Until: now.Add(time.Hour)},
+			{Root: "github.com/expired/repo", Until: now.Add(-time.Hour)},
+		},
+	}
+	tests := []struct {
+		root, localRevision string
+		skip                bool
+	}{
+		{"github.com/foo/bar", "", true},
+		{"github.com/foo/barbaz", "", false},
+		{"golang.org/x/net", "", true},
+		{"golang.org/x", "", true},
+		{"golang.org/xyz", "", false},
+		{"github.com/pinned/repo", "abc", true},
+		{"github.com/pinned/repo", "def", false},
+		{"github.com/snoozed/repo", "", true},
+		{"github.com/expired/repo", "", false},
+	}
+	for _, tc := range tests {
+		if got := c.Skip(tc.root, tc.localRevision, now) != ""; got != tc.skip {
+			t.Errorf("Skip(%q, %q): got skip %v, want %v", tc.root, tc.localRevision, got, tc.skip)
+		}
+	}
+}
+
+func TestFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "sub", "config.json")
+
+	f, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Ignore("github.com/foo/bar"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Snooze("github.com/baz/qux", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reopen to check that changes were persisted.
+	f, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Skip("github.com/foo/bar", "") == "" {
+		t.Error("github.com/foo/bar is not ignored after reopening")
+	}
+	if f.Skip("github.com/baz/qux", "") == "" {
+		t.Error("github.com/baz/qux is not snoozed after reopening")
+	}
+	if reason := f.Skip("github.com/other/repo", ""); reason != "" {
+		t.Errorf("github.com/other/repo is unexpectedly skipped: %v", reason)
+	}
+}
@@ -10,6 +10,9 @@ type Action interface{}
 type Response interface{}
 
 // AppendRP is an action for appending a single update to the end.
+// If the same update of RepoRoot was already appended, only its ImportedBy
+// is updated instead, since the backend sends an update again once it knows
+// which packages in the workspace import it.
 type AppendRP struct {
 	RP *model.RepoPresentation
 }
@@ -28,9 +31,77 @@ type SetUpdatingAllResponse struct {
 	RepoRoots []string
 }
 
+// SetSelected is an action for selecting or deselecting an update with RepoRoot
+// for updating in a batch.
+type SetSelected struct {
+	RepoRoot string
+	Selected bool
+}
+
+// SetUpdatingSelected is an action for setting all selected available updates
+// to updating state.
+type SetUpdatingSelected struct{}
+
+// SetUpdatingSelectedResponse is the response from SetUpdatingSelected action,
+// listing RepoRoot of all updates that were affected.
+type SetUpdatingSelectedResponse struct {
+	RepoRoots []string
+}
+
+// SetUpdateFailed is an action for setting updates with RepoRoots back to
+// available state after updating them failed with Error.
+type SetUpdateFailed struct {
+	RepoRoots []string
+	Error     string
+}
+
 // SetUpdated is an action for setting an update with RepoRoot to updated state.
+// If Verification reports the update was rolled back, it's set to available state instead.
 type SetUpdated struct {
+	RepoRoot      string
+	Verification  *model.Verification  // Nil means the update wasn't verified.
+	StashConflict *model.StashConflict // Nil means there were no local changes that couldn't be re-applied.
+}
+
+// SetPlan is an action for setting what updating a repo with RepoRoot would do.
+// Nil Plan hides the preview.
+type SetPlan struct {
 	RepoRoot string
+	Plan     *model.Plan
+}
+
+// SetReverted is an action for setting a completed update with RepoRoot
+// back to available state, after it was reverted.
+type SetReverted struct {
+	RepoRoot string
+}
+
+// Remove is an action for removing an available update with RepoRoot,
+// e.g., because it was ignored or snoozed.
+type Remove struct {
+	RepoRoot string
+}
+
+// SetRateLimit is an action for setting the remote API rate limit
+// that checking for updates is paused on. Nil RateLimit means it's not paused.
+type SetRateLimit struct {
+	RateLimit *model.RateLimit
+}
+
+// SetTab is an action for switching to Tab.
+type SetTab struct {
+	Tab model.Tab
+}
+
+// SetHistoryLog is an action for setting the history log of updates.
+type SetHistoryLog struct {
+	Entries []model.HistoryEntry
+}
+
+// SetHistoryQuery is an action for setting the query that
+// the history log is searched with. Empty Query matches all entries.
+type SetHistoryQuery struct {
+	Query string
 }
 
 // DoneCheckingUpdates is an action for when the update checking process is completed.
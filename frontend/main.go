@@ -26,6 +26,15 @@ var document = dom.GetWindow().Document().(dom.HTMLDocument)
 func main() {
 	js.Global.Set("UpdateRepository", UpdateRepository)
 	js.Global.Set("UpdateAll", UpdateAll)
+	js.Global.Set("UpdateSelected", UpdateSelected)
+	js.Global.Set("SelectRepository", SelectRepository)
+	js.Global.Set("IgnoreRepository", IgnoreRepository)
+	js.Global.Set("SnoozeRepository", SnoozeRepository)
+	js.Global.Set("RevertRepository", RevertRepository)
+	js.Global.Set("PreviewRepository", PreviewRepository)
+	js.Global.Set("ClosePreview", ClosePreview)
+	js.Global.Set("SwitchTab", SwitchTab)
+	js.Global.Set("SearchHistory", SearchHistory)
 
 	switch readyState := document.ReadyState(); readyState {
 	case "loading":
@@ -49,8 +58,13 @@ func run() {
 	// Start the scheduler loop.
 	go scheduler()
 
+	// Poll the status of checking for updates while streaming.
+	done := make(chan struct{})
+	go pollStatus(done)
+
 	// Start streaming repo presentations from the backend.
 	err = stream()
+	close(done)
 	if err != nil {
 		log.Println(err)
 	}
@@ -84,6 +98,43 @@ func stream() error {
 	return nil
 }
 
+// statusPollInterval is how often pollStatus fetches the status.
+const statusPollInterval = 10 * time.Second
+
+// pollStatus periodically fetches the status of checking for updates
+// from the backend, and applies it to the store, until done is closed.
+func pollStatus(done <-chan struct{}) {
+	for {
+		select {
+		case <-time.After(statusPollInterval):
+		case <-done:
+			return
+		}
+
+		status, err := fetchStatus()
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		apply(&action.SetRateLimit{RateLimit: status.RateLimit})
+	}
+}
+
+// fetchStatus fetches the status of checking for updates from the backend.
+func fetchStatus() (model.Status, error) {
+	resp, err := http.Get("/api/status")
+	if err != nil {
+		return model.Status{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return model.Status{}, fmt.Errorf("non-200 status code: %v", resp.StatusCode)
+	}
+	var status model.Status
+	err = json.NewDecoder(resp.Body).Decode(&status)
+	return status, err
+}
+
 // scheduler runs a loop that is responsible for
 // applying actions to the store as they're made available,
 // and rendering the body after processing new actions.
@@ -134,18 +185,27 @@ func renderBody() {
 
 var body = &UpdatesBody{}
 
-// UpdatesBody is the entire body of the updates tab.
+// UpdatesBody is the entire body of the selected tab.
 type UpdatesBody struct {
 	vecty.Core
 }
 
 // Render renders the component.
 func (b *UpdatesBody) Render() vecty.ComponentOrHTML {
+	if store.Tab() == model.HistoryTab {
+		return elem.Body(
+			gpscomponent.HistoryContent(
+				store.HistoryLog(),
+				store.HistoryQuery(),
+			)...,
+		)
+	}
 	return elem.Body(
 		gpscomponent.UpdatesContent(
 			store.Active(),
 			store.History(),
 			store.CheckingUpdates(),
+			store.RateLimit(),
 		)...,
 	)
 }
@@ -164,6 +224,57 @@ func UpdateAll() {
 	}()
 }
 
+// SelectRepository selects or deselects specified repository for updating in a batch.
+// root is the import path corresponding to the root of the repository.
+func SelectRepository(root string, selected bool) {
+	go apply(&action.SetSelected{RepoRoot: root, Selected: selected})
+}
+
+// UpdateSelected marks all selected available updates as updating, and performs
+// them in background as a single batch. If any of them fails, the whole batch
+// is rolled back by the backend.
+func UpdateSelected() {
+	go func() {
+		started := time.Now()
+		defer func() { fmt.Println("update selected:", time.Since(started)) }()
+
+		resp := apply(&action.SetUpdatingSelected{}).(*action.SetUpdatingSelectedResponse)
+		if len(resp.RepoRoots) == 0 {
+			return
+		}
+
+		result, err := updateBatch(resp.RepoRoots)
+		if err != nil {
+			log.Println(err)
+			apply(&action.SetUpdateFailed{RepoRoots: resp.RepoRoots, Error: err.Error()})
+			return
+		}
+		if result.Error != "" {
+			apply(&action.SetUpdateFailed{RepoRoots: resp.RepoRoots, Error: result.Error})
+			return
+		}
+		for _, root := range resp.RepoRoots {
+			apply(&action.SetUpdated{RepoRoot: root})
+		}
+	}()
+}
+
+// updateBatch updates specified repositories as a single batch.
+func updateBatch(roots []string) (model.BatchResult, error) {
+	resp, err := http.PostForm("/api/update-batch", url.Values{"RepoRoot": roots})
+	if err != nil {
+		return model.BatchResult{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return model.BatchResult{}, fmt.Errorf("batch update failed: %v: %s", resp.Status, body)
+	}
+	var result model.BatchResult
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	return result, err
+}
+
 // UpdateRepository updates specified repository.
 // root is the import path corresponding to the root of the repository.
 func UpdateRepository(root string) {
@@ -182,20 +293,155 @@ func update(root string) {
 	started := time.Now()
 	defer func() { fmt.Println("update:", time.Since(started)) }()
 
-	resp, err := http.PostForm("/api/update", url.Values{"RepoRoot": {root}})
+	result, err := updateResult(root)
 	if err != nil {
 		log.Println(err)
+		apply(&action.SetUpdateFailed{RepoRoots: []string{root}, Error: err.Error()})
 		return
 	}
+	if result.Error != "" {
+		apply(&action.SetUpdateFailed{RepoRoots: []string{root}, Error: result.Error})
+		return
+	}
+	apply(&action.SetUpdated{RepoRoot: root, Verification: result.Verification, StashConflict: result.StashConflict})
+}
+
+// updateResult requests specified repository to be updated, and returns the result.
+func updateResult(root string) (model.UpdateResult, error) {
+	resp, err := http.PostForm("/api/update", url.Values{"RepoRoot": {root}})
+	if err != nil {
+		return model.UpdateResult{}, err
+	}
 	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return model.UpdateResult{}, fmt.Errorf("update failed: %v: %s", resp.Status, body)
+	}
+	var result model.UpdateResult
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	return result, err
+}
+
+// PreviewRepository previews what updating specified repository would do.
+// root is the import path corresponding to the root of the repository.
+func PreviewRepository(root string) {
+	go func() {
+		resp, err := http.Get("/api/plan?root=" + url.QueryEscape(root))
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			body, _ := ioutil.ReadAll(resp.Body)
+			log.Printf("previewing %v failed: %v: %s\n", root, resp.Status, body)
+			return
+		}
+		var plan model.Plan
+		err = json.NewDecoder(resp.Body).Decode(&plan)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+
+		apply(&action.SetPlan{RepoRoot: root, Plan: &plan})
+	}()
+}
+
+// ClosePreview hides the preview of updating specified repository.
+// root is the import path corresponding to the root of the repository.
+func ClosePreview(root string) {
+	go apply(&action.SetPlan{RepoRoot: root, Plan: nil})
+}
+
+// RevertRepository reverts a completed update of specified repository
+// to the previous revision, making the update available again.
+// root is the import path corresponding to the root of the repository.
+func RevertRepository(root string) {
+	go func() {
+		started := time.Now()
+		defer func() { fmt.Println("revert:", time.Since(started)) }()
+
+		resp, err := http.PostForm("/api/revert", url.Values{"RepoRoot": {root}})
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			body, _ := ioutil.ReadAll(resp.Body)
+			log.Printf("reverting %v failed: %v: %s\n", root, resp.Status, body)
+			return
+		}
+
+		apply(&action.SetReverted{RepoRoot: root})
+	}()
+}
 
-	// TODO: Check response for success or not, etc.
-	//       This is a great chance to display update errors in frontend!
-	_, err = io.Copy(ioutil.Discard, resp.Body)
+// snoozeDuration is how long SnoozeRepository snoozes updates for.
+const snoozeDuration = 7 * 24 * time.Hour
+
+// IgnoreRepository stops offering updates for specified repository permanently.
+// root is the import path corresponding to the root of the repository.
+func IgnoreRepository(root string) {
+	go ignore(root, url.Values{"RepoRoot": {root}})
+}
+
+// SnoozeRepository stops offering updates for specified repository for snoozeDuration.
+// root is the import path corresponding to the root of the repository.
+func SnoozeRepository(root string) {
+	go ignore(root, url.Values{"RepoRoot": {root}, "Snooze": {snoozeDuration.String()}})
+}
+
+// ignore asks the backend to ignore or snooze specified repository,
+// and removes it from the store if successful.
+func ignore(root string, form url.Values) {
+	resp, err := http.PostForm("/api/ignore", form)
 	if err != nil {
 		log.Println(err)
 		return
 	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		log.Printf("ignoring %v failed: %v: %s\n", root, resp.Status, body)
+		return
+	}
+
+	apply(&action.Remove{RepoRoot: root})
+}
 
-	apply(&action.SetUpdated{RepoRoot: root})
+// SwitchTab switches to specified tab. The history log is fetched
+// from the backend each time the history tab is switched to.
+func SwitchTab(tab model.Tab) {
+	go func() {
+		if tab == model.HistoryTab {
+			entries, err := fetchHistory()
+			if err != nil {
+				log.Println(err)
+			}
+			apply(&action.SetHistoryLog{Entries: entries})
+		}
+		apply(&action.SetTab{Tab: tab})
+	}()
+}
+
+// SearchHistory searches the history log with specified query.
+func SearchHistory(query string) {
+	go apply(&action.SetHistoryQuery{Query: query})
+}
+
+// fetchHistory fetches the history log of updates from the backend.
+func fetchHistory() ([]model.HistoryEntry, error) {
+	resp, err := http.Get("/api/history")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("non-200 status code: %v", resp.StatusCode)
+	}
+	var entries []model.HistoryEntry
+	err = json.NewDecoder(resp.Body).Decode(&entries)
+	return entries, err
 }
@@ -1,6 +1,8 @@
 // Package model is a frontend data model for updates.
 package model
 
+import "time"
+
 // RepoPresentation represents a repository update presentation.
 //
 // TODO: Dedup with workspace.RepoPresentation. Maybe.
@@ -9,15 +11,133 @@ type RepoPresentation struct {
 	ImportPathPattern string
 	LocalRevision     string
 	RemoteRevision    string
+	TrackedBranch     string // Upstream branch that updates are tracked on, if it's not the default branch.
 	HomeURL           string
 	ImageURL          string
 	Changes           []Change // TODO: Consider []*Change.
+	TotalChanges      int      // Total count of changes. It may be greater than len(Changes).
+	Releases          []Release
+	Advisories        []Advisory     // Known vulnerabilities fixed by the update.
+	LicenseChange     *LicenseChange // Nil means license didn't change, or it wasn't checked.
+	Diffstat          *Diffstat      // Nil means not known.
 	Error             string
 
+	// IncompatibleChanges describes incompatible changes to exported APIs
+	// between local and remote revisions.
+	IncompatibleChanges []string
+
+	// ImportedBy are import paths of Go packages in the workspace
+	// that import packages in this repository.
+	ImportedBy []string
+
+	// DirtyStatus is the status of the local working tree if it's dirty.
+	// Empty means it's clean.
+	DirtyStatus string
+
+	// Fork describes the canonical upstream repository, if the repository
+	// is cloned from a fork of it. Nil means it's not a fork.
+	Fork *Fork
+
+	// Diverged describes local commits that aren't on the remote branch,
+	// if the local branch has diverged from it. Updating a diverged repo
+	// rebases the local commits. Nil means it hasn't diverged.
+	Diverged *Divergence
+
+	// Stale is the time the remote state was fetched at, if it's the last-known
+	// remote state from a previous run, because the remote couldn't be reached.
+	// Zero means the remote state is current.
+	Stale time.Time
+
 	UpdateState UpdateState
 
 	// TODO: Find a place for this.
 	UpdateSupported bool
+	UpdateRefused   string // Reason why updating is refused, if any.
+	IgnoreSupported bool   // Ignoring and snoozing the update is supported.
+	RevertSupported bool   // Reverting the update once it's completed is supported.
+	PlanSupported   bool   // Previewing what updating would do is supported.
+
+	// Selected reports whether the update is selected for updating in a batch.
+	// Batch updates are supported if reverting is supported.
+	Selected bool
+
+	// UpdateError is the error that the last attempt to update failed with, if any.
+	UpdateError string
+
+	// Plan is what updating would do, once it's been previewed.
+	// Nil means it's not previewed.
+	Plan *Plan
+
+	// PreviousRevision is the local revision before the update.
+	// It's set once the update is completed.
+	PreviousRevision string
+
+	// Verification is the result of verifying the update once it's completed.
+	// Nil means it wasn't verified.
+	Verification *Verification
+
+	// StashConflict describes local changes that couldn't be re-applied
+	// once the update was completed. Nil means there weren't any.
+	StashConflict *StashConflict
+}
+
+// StashConflict describes stashed local changes that couldn't be re-applied
+// after an update was completed. They're kept in the stash.
+type StashConflict struct {
+	Error string   // Error that re-applying the stashed local changes failed with.
+	Files []string // Files with unresolved conflicts, if any.
+}
+
+// UpdateResult is the result of updating a repository.
+type UpdateResult struct {
+	Error         string         // Error that the update failed with. Empty means success.
+	Verification  *Verification  // Nil means the update wasn't verified.
+	StashConflict *StashConflict // Nil means there were no local changes that couldn't be re-applied.
+}
+
+// BatchResult is the result of updating a batch of repositories.
+type BatchResult struct {
+	Error      string // Error that the batch update failed with. Empty means success.
+	RolledBack bool   // All updates in the batch were rolled back after a failure.
+}
+
+// Fork describes the canonical upstream repository of a fork.
+type Fork struct {
+	ForkURL          string // URL of the fork that updates are pulled from.
+	UpstreamURL      string // URL of the canonical upstream repository.
+	UpstreamBranch   string // Default branch of the upstream repository.
+	UpstreamRevision string // Revision of the default branch.
+	Behind           int    // Number of upstream commits that the fork doesn't have.
+}
+
+// Divergence describes how a local branch diverged from the remote branch.
+type Divergence struct {
+	MergeBase    string   // Best common ancestor of local and remote revisions. Empty means not known.
+	LocalCommits []Commit // Local commits that aren't on the remote branch, newest first.
+}
+
+// Commit is a commit in a repository.
+type Commit struct {
+	Revision string
+	Message  string // First line of the commit message.
+}
+
+// Plan describes what updating a repository would do.
+type Plan struct {
+	LocalRevision  string
+	RemoteRevision string
+	Commands       []string // Commands that would be executed, in order.
+	Edits          []string // Files that would be modified by means other than Commands.
+	DryRun         bool     // Dry run mode is enabled, so the update can't be confirmed.
+}
+
+// Verification is the result of verifying a completed update
+// by building or testing Go packages affected by it.
+type Verification struct {
+	Command    string // Command that was run, e.g., "go build github.com/foo/bar".
+	Error      string // Error that verification failed with. Empty means success.
+	Output     string // Output of the command.
+	RolledBack bool   // The update was rolled back because verification failed.
 }
 
 // UpdateState represents the state of an update.
@@ -36,9 +156,72 @@ const (
 
 // Change represents a single commit message.
 type Change struct {
-	Message  string   // Commit message of this change.
-	URL      string   // URL of this change.
-	Comments Comments // Comments on this change.
+	Message  string    // First paragraph of commit message of this change.
+	Body     string    // Rest of commit message of this change.
+	Author   Author    // Author of this change.
+	Time     time.Time // Time this change was authored. Zero value means unknown.
+	URL      string    // URL of this change.
+	Comments Comments  // Comments on this change.
+}
+
+// Author represents the author of a change.
+type Author struct {
+	Name      string
+	AvatarURL string
+}
+
+// Release represents a release or a tag crossed by an update.
+type Release struct {
+	Tag  string
+	Name string    // Release name, or tag name if there's no release.
+	Date time.Time // Zero value means unknown.
+	Body string    // Release notes, as sanitized HTML.
+	URL  string
+}
+
+// Diffstat summarizes changes to files in an update, grouped by Go package.
+type Diffstat struct {
+	Additions  int
+	Deletions  int
+	Packages   []PackageDiffstat // Sorted by import path.
+	Incomplete bool              // Packages may be missing some of the changed files.
+}
+
+// PackageDiffstat summarizes changes to files that belong to a single Go package,
+// including files in its subdirectories that aren't Go packages, e.g., testdata.
+type PackageDiffstat struct {
+	ImportPath string
+	Additions  int
+	Deletions  int
+	Files      []FileChange
+}
+
+// FileChange represents changes to a single file.
+type FileChange struct {
+	Name      string // Slash-separated path of the file, relative to the package directory.
+	Additions int
+	Deletions int
+}
+
+// LicenseChange represents a change of license type.
+// License type is "Unknown" at a revision where it couldn't be determined.
+type LicenseChange struct {
+	From string
+	To   string
+}
+
+// Unknown reports whether the license type couldn't be determined
+// at either revision, so it may have changed.
+func (lc LicenseChange) Unknown() bool {
+	return lc.From == "Unknown" || lc.To == "Unknown"
+}
+
+// Advisory represents a known vulnerability.
+type Advisory struct {
+	ID      string
+	Aliases []string
+	Summary string
+	URL     string
 }
 
 // Comments represents a change discussion.
@@ -48,3 +231,39 @@ type Comments struct {
 	Count int
 	URL   string
 }
+
+// HistoryEntry represents a single update in the persistent history log.
+type HistoryEntry struct {
+	RepoRoot       string
+	LocalRevision  string        // Revision before the update.
+	RemoteRevision string        // Revision the update was to.
+	Time           time.Time     // Time the update finished.
+	Updater        string        // Updater that performed the update.
+	Error          string        // Error that the update failed with. Empty means success.
+	Output         string        // Captured output of the updater.
+	Verification   *Verification // Nil means the update wasn't verified.
+}
+
+// Tab represents a tab of the frontend.
+type Tab uint8
+
+const (
+	// UpdatesTab is the tab with available updates.
+	UpdatesTab Tab = iota
+
+	// HistoryTab is the tab with the history log of updates.
+	HistoryTab
+)
+
+// Status represents the status of the process of checking for updates.
+type Status struct {
+	// RateLimit is the remote API rate limit that checking for updates
+	// is paused on, until it resets. Nil means it's not paused.
+	RateLimit *RateLimit
+}
+
+// RateLimit represents a remote API rate limit that was reached.
+type RateLimit struct {
+	Service string    // Name of the remote API, e.g., "GitHub API".
+	Reset   time.Time // Time when the rate limit resets.
+}
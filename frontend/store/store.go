@@ -4,19 +4,27 @@ package store
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/shurcooL/Go-Package-Store/frontend/action"
 	"github.com/shurcooL/Go-Package-Store/frontend/model"
 )
 
 var (
-	active          []*model.RepoPresentation // Latest at the end.
+	active          []*model.RepoPresentation // Sorted by priority, latest at the end among equals.
 	history         []*model.RepoPresentation // Latest at the end.
 	checkingUpdates = true
+	rateLimit       *model.RateLimit
+	tab             model.Tab
+	historyLog      []model.HistoryEntry // Oldest first.
+	historyQuery    string
 )
 
-// Active returns the active repo presentations in store.
-// Most recently added ones are last.
+// Active returns the active repo presentations in store, sorted by priority:
+// updates that fix known vulnerabilities are first, then repos imported
+// by most packages in the workspace. Among repos with equal priority,
+// most recently added ones are last.
 func Active() []*model.RepoPresentation { return active }
 
 // History returns the historical repo presentations in store.
@@ -26,13 +34,85 @@ func History() []*model.RepoPresentation { return history }
 // CheckingUpdates reports whether the process of checking for updates is still running.
 func CheckingUpdates() bool { return checkingUpdates }
 
+// RateLimit returns the remote API rate limit that checking for updates
+// is paused on, or nil if it's not paused.
+func RateLimit() *model.RateLimit { return rateLimit }
+
+// Tab returns the current tab.
+func Tab() model.Tab { return tab }
+
+// HistoryLog returns the entries of the persistent history log of updates
+// that match the history query. Most recent ones are first.
+func HistoryLog() []model.HistoryEntry {
+	var entries []model.HistoryEntry
+	for i := len(historyLog) - 1; i >= 0; i-- {
+		if matchHistoryEntry(historyLog[i], historyQuery) {
+			entries = append(entries, historyLog[i])
+		}
+	}
+	return entries
+}
+
+// HistoryQuery returns the query that the history log is searched with.
+func HistoryQuery() string { return historyQuery }
+
+// matchHistoryEntry reports whether history entry e matches query.
+// Each space separated term in query must be a case-insensitive substring
+// of the repo root, revisions, updater or error of e.
+func matchHistoryEntry(e model.HistoryEntry, query string) bool {
+	text := strings.ToLower(strings.Join([]string{e.RepoRoot, e.LocalRevision, e.RemoteRevision, e.Updater, e.Error}, " "))
+	for _, term := range strings.Fields(strings.ToLower(query)) {
+		if !strings.Contains(text, term) {
+			return false
+		}
+	}
+	return true
+}
+
+// lessPriority reports whether update a has less priority than update b.
+func lessPriority(a, b *model.RepoPresentation) bool {
+	if (len(a.Advisories) > 0) != (len(b.Advisories) > 0) {
+		return len(b.Advisories) > 0
+	}
+	return len(a.ImportedBy) < len(b.ImportedBy)
+}
+
+// insertActive inserts rp into active, keeping it sorted by priority.
+func insertActive(rp *model.RepoPresentation) {
+	i := sort.Search(len(active), func(i int) bool {
+		return lessPriority(active[i], rp)
+	})
+	active = append(active, nil)
+	copy(active[i+1:], active[i:])
+	active[i] = rp
+}
+
 // Apply applies action a to the store.
 func Apply(a action.Action) action.Response {
 	switch a := a.(type) {
 	case *action.AppendRP:
+		same := func(rp *model.RepoPresentation) bool {
+			return rp.RepoRoot == a.RP.RepoRoot && rp.LocalRevision == a.RP.LocalRevision && rp.RemoteRevision == a.RP.RemoteRevision
+		}
+		for i, rp := range active {
+			if same(rp) {
+				// Reinsert, since priority depends on ImportedBy.
+				copy(active[i:], active[i+1:])
+				active = active[:len(active)-1]
+				rp.ImportedBy = a.RP.ImportedBy
+				insertActive(rp)
+				return nil
+			}
+		}
+		for _, rp := range history {
+			if same(rp) {
+				rp.ImportedBy = a.RP.ImportedBy
+				return nil
+			}
+		}
 		switch a.RP.UpdateState {
 		case model.Available, model.Updating:
-			active = append(active, a.RP)
+			insertActive(a.RP)
 		case model.Updated:
 			history = append(history, a.RP)
 		}
@@ -42,6 +122,8 @@ func Apply(a action.Action) action.Response {
 		for _, rp := range active {
 			if rp.RepoRoot == a.RepoRoot {
 				rp.UpdateState = model.Updating
+				rp.Plan = nil
+				rp.UpdateError = ""
 				return nil
 			}
 		}
@@ -50,9 +132,12 @@ func Apply(a action.Action) action.Response {
 	case *action.SetUpdatingAll:
 		var repoRoots []string
 		for _, rp := range active {
-			if rp.UpdateState == model.Available {
+			// Diverged repos are rebased, which is only done when requested individually.
+			if rp.UpdateState == model.Available && rp.UpdateRefused == "" && rp.Diverged == nil {
 				repoRoots = append(repoRoots, rp.RepoRoot)
 				rp.UpdateState = model.Updating
+				rp.Plan = nil
+				rp.UpdateError = ""
 			}
 		}
 		// TODO: Instead of response, look into async-action-creators:
@@ -60,15 +145,58 @@ func Apply(a action.Action) action.Response {
 		//       -	https://gophers.slack.com/archives/D02LBN6UW/p1488335043280451
 		return &action.SetUpdatingAllResponse{RepoRoots: repoRoots}
 
+	case *action.SetSelected:
+		for _, rp := range active {
+			if rp.RepoRoot == a.RepoRoot {
+				rp.Selected = a.Selected
+				return nil
+			}
+		}
+		panic(fmt.Errorf("RepoRoot %q was not found in store", a.RepoRoot))
+
+	case *action.SetUpdatingSelected:
+		var repoRoots []string
+		for _, rp := range active {
+			if rp.Selected && rp.UpdateState == model.Available && rp.UpdateRefused == "" {
+				repoRoots = append(repoRoots, rp.RepoRoot)
+				rp.UpdateState = model.Updating
+				rp.Selected = false
+				rp.Plan = nil
+				rp.UpdateError = ""
+			}
+		}
+		return &action.SetUpdatingSelectedResponse{RepoRoots: repoRoots}
+
+	case *action.SetUpdateFailed:
+		for _, root := range a.RepoRoots {
+			for _, rp := range active {
+				if rp.RepoRoot == root {
+					rp.UpdateState = model.Available
+					rp.UpdateError = a.Error
+					break
+				}
+			}
+		}
+		return nil
+
 	case *action.SetUpdated:
 		for i, rp := range active {
 			if rp.RepoRoot == a.RepoRoot {
+				rp.Verification = a.Verification
+				rp.StashConflict = a.StashConflict
+				if a.Verification != nil && a.Verification.RolledBack {
+					// Keep in active, since the update was rolled back.
+					rp.UpdateState = model.Available
+					return nil
+				}
+
 				// Remove from active.
 				copy(active[i:], active[i+1:])
 				active = active[:len(active)-1]
 
 				// Set UpdateState.
 				rp.UpdateState = model.Updated
+				rp.PreviousRevision = rp.LocalRevision
 
 				// Append to history.
 				history = append(history, rp)
@@ -78,8 +206,67 @@ func Apply(a action.Action) action.Response {
 		}
 		panic(fmt.Errorf("RepoRoot %q was not found in store", a.RepoRoot))
 
+	case *action.SetPlan:
+		for _, rp := range active {
+			if rp.RepoRoot == a.RepoRoot {
+				rp.Plan = a.Plan
+				return nil
+			}
+		}
+		panic(fmt.Errorf("RepoRoot %q was not found in store", a.RepoRoot))
+
+	case *action.SetReverted:
+		// Only completed updates of this session have a previous revision to revert to,
+		// and the latest of them is the one that was reverted.
+		for i := len(history) - 1; i >= 0; i-- {
+			if rp := history[i]; rp.RepoRoot == a.RepoRoot && rp.PreviousRevision != "" {
+				// Remove from history.
+				copy(history[i:], history[i+1:])
+				history = history[:len(history)-1]
+
+				// Set UpdateState.
+				rp.UpdateState = model.Available
+				rp.PreviousRevision = ""
+				rp.Verification = nil
+				rp.StashConflict = nil
+
+				// Insert into active.
+				insertActive(rp)
+
+				return nil
+			}
+		}
+		panic(fmt.Errorf("RepoRoot %q was not found in store", a.RepoRoot))
+
+	case *action.Remove:
+		for i, rp := range active {
+			if rp.RepoRoot == a.RepoRoot {
+				copy(active[i:], active[i+1:])
+				active = active[:len(active)-1]
+				return nil
+			}
+		}
+		panic(fmt.Errorf("RepoRoot %q was not found in store", a.RepoRoot))
+
+	case *action.SetRateLimit:
+		rateLimit = a.RateLimit
+		return nil
+
+	case *action.SetTab:
+		tab = a.Tab
+		return nil
+
+	case *action.SetHistoryLog:
+		historyLog = a.Entries
+		return nil
+
+	case *action.SetHistoryQuery:
+		historyQuery = a.Query
+		return nil
+
 	case *action.DoneCheckingUpdates:
 		checkingUpdates = false
+		rateLimit = nil
 		return nil
 
 	default:
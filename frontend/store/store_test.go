@@ -0,0 +1,32 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/shurcooL/Go-Package-Store/frontend/action"
+	"github.com/shurcooL/Go-Package-Store/frontend/model"
+)
+
+func TestSetReverted(t *testing.T) {
+	active, history = nil, nil
+
+	// An update restored from an earlier run, which can't be reverted,
+	// and an update of the same repo completed in this session.
+	restored := &model.RepoPresentation{RepoRoot: "example.com/repo", LocalRevision: "a", RemoteRevision: "b", UpdateState: model.Updated}
+	current := &model.RepoPresentation{RepoRoot: "example.com/repo", LocalRevision: "b", RemoteRevision: "c", UpdateState: model.Available}
+	Apply(&action.AppendRP{RP: restored})
+	Apply(&action.AppendRP{RP: current})
+	Apply(&action.SetUpdating{RepoRoot: "example.com/repo"})
+	Apply(&action.SetUpdated{RepoRoot: "example.com/repo"})
+	if len(history) != 2 || history[1] != current || current.PreviousRevision != "b" {
+		t.Fatalf("got history %v, want restored and current updates", history)
+	}
+
+	Apply(&action.SetReverted{RepoRoot: "example.com/repo"})
+	if len(history) != 1 || history[0] != restored {
+		t.Errorf("got history %v, want only the restored update", history)
+	}
+	if len(active) != 1 || active[0] != current || current.UpdateState != model.Available {
+		t.Errorf("got active %v, want the current update available again", active)
+	}
+}
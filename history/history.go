@@ -0,0 +1,171 @@
+// Package history provides a persistent log of updates performed by Go Package Store.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/shurcooL/Go-Package-Store/config"
+)
+
+// Entry is a single update in the log.
+type Entry struct {
+	Root    string    // Import path corresponding to the root of the repository.
+	From    string    // Revision before the update.
+	To      string    // Revision the update was to.
+	Time    time.Time // Time the update finished.
+	Updater string    // Updater that performed the update.
+	Error   string    // Error that the update failed with. Empty means success.
+	Output  string    // Captured output of the updater.
+
+	// Verification is the result of verifying the update. Nil means it wasn't verified.
+	Verification *Verification `json:",omitempty"`
+}
+
+// Verification is the result of verifying an update
+// by building or testing Go packages affected by it.
+type Verification struct {
+	Command    string // Command that was run.
+	Error      string // Error that verification failed with. Empty means success.
+	Output     string // Output of the command.
+	RolledBack bool   // The update was rolled back because verification failed.
+}
+
+// Path returns the path of the history log in the user configuration directory.
+func Path() (string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "history.jsonl"), nil
+}
+
+const (
+	// maxLogSize is the size of the log file after which it's rotated.
+	// The previous log file is kept, so the log uses at most about twice as much.
+	maxLogSize = 1 << 20
+
+	// maxOutputSize is the maximum size of captured output stored in an entry.
+	// Longer output is truncated, keeping its end, where errors usually are.
+	maxOutputSize = 16 << 10
+)
+
+// Log is a history log stored in a file, one JSON encoded entry per line.
+// Once the file grows over maxLogSize, it's rotated into a file with ".1" suffix,
+// replacing an earlier rotated file.
+// It's safe for concurrent use.
+type Log struct {
+	path string
+
+	mu sync.Mutex
+}
+
+// NewLog returns a history log stored in a file at path.
+// The file is created when the first entry is appended.
+func NewLog(path string) *Log {
+	return &Log{path: path}
+}
+
+// Append appends entry e to the log.
+// Captured output longer than maxOutputSize is truncated.
+func (l *Log) Append(e Entry) error {
+	e.Output = truncateOutput(e.Output)
+	if v := e.Verification; v != nil {
+		vc := *v
+		vc.Output = truncateOutput(v.Output)
+		e.Verification = &vc
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	err = os.MkdirAll(filepath.Dir(l.path), 0700)
+	if err != nil {
+		return err
+	}
+	if fi, err := os.Stat(l.path); err == nil && fi.Size() >= maxLogSize {
+		err := os.Rename(l.path, l.path+".1")
+		if err != nil {
+			return err
+		}
+	}
+	f, err := os.OpenFile(l.path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	// Start on a new line if the last line was only partially written.
+	if fi, err := f.Stat(); err == nil && fi.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := f.ReadAt(last, fi.Size()-1); err == nil && last[0] != '\n' {
+			b = append([]byte{'\n'}, b...)
+		}
+	}
+	_, err = f.Write(append(b, '\n'))
+	if err1 := f.Close(); err == nil {
+		err = err1
+	}
+	return err
+}
+
+// truncateOutput returns output truncated to at most maxOutputSize bytes, keeping its end.
+func truncateOutput(output string) string {
+	const marker = "[output truncated]\n"
+	if len(output) <= maxOutputSize {
+		return output
+	}
+	return marker + output[len(output)-(maxOutputSize-len(marker)):]
+}
+
+// Entries returns all entries in the log, including the rotated file, oldest first.
+// Lines that can't be decoded, such as a partially written last line, are skipped.
+func (l *Log) Entries() ([]Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var entries []Entry
+	for _, path := range []string{l.path + ".1", l.path} {
+		es, err := decodeFile(path)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, es...)
+	}
+	return entries, nil
+}
+
+// decodeFile decodes entries in file at path. A missing file has no entries.
+func decodeFile(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return decode(f)
+}
+
+func decode(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(line) > 0 {
+			var e Entry
+			if json.Unmarshal(line, &e) == nil {
+				entries = append(entries, e)
+			}
+		}
+		if err == io.EOF {
+			return entries, nil
+		} else if err != nil {
+			return entries, err
+		}
+	}
+}
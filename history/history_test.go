@@ -0,0 +1,96 @@
+package history
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "sub", "history.jsonl")
+	l := NewLog(path)
+
+	entries, err := l.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("got %v entries in new log, want 0", len(entries))
+	}
+
+	want := []Entry{
+		{Root: "github.com/foo/bar", From: "a", To: "b", Time: time.Unix(1500000000, 0).UTC(), Updater: "updater.Gopath", Output: "git pull --ff-only\n"},
+		{Root: "github.com/baz/qux", From: "c", To: "d", Time: time.Unix(1500000100, 0).UTC(), Updater: "updater.Gopath", Error: "exit status 1"},
+	}
+	for _, e := range want {
+		if err := l.Append(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Simulate a partially written last line.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"Root":"github.com/trunc`)
+	f.Close()
+	// An entry appended afterwards should not be lost.
+	last := Entry{Root: "github.com/last/repo", From: "e", To: "f", Time: time.Unix(1500000200, 0).UTC(), Updater: "updater.Dep"}
+	if err := l.Append(last); err != nil {
+		t.Fatal(err)
+	}
+	want = append(want, last)
+
+	got, err := NewLog(path).Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got entries:\n%+v\nwant:\n%+v", got, want)
+	}
+}
+
+func TestLogRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	l := NewLog(filepath.Join(dir, "history.jsonl"))
+
+	// Each entry has more output than is stored, so the log is rotated twice.
+	const n = 3 * maxLogSize / maxOutputSize
+	output := strings.Repeat("x", maxOutputSize) + "error at the end\n"
+	for i := 0; i < n; i++ {
+		if err := l.Append(Entry{Root: fmt.Sprintf("example.com/repo%d", i), Output: output}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := l.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) == 0 || len(entries) >= n {
+		t.Fatalf("got %v entries, want fewer than %v after rotation", len(entries), n)
+	}
+	for i, e := range entries {
+		if want := fmt.Sprintf("example.com/repo%d", n-len(entries)+i); e.Root != want {
+			t.Fatalf("got entry %v with root %q, want %q", i, e.Root, want)
+		}
+	}
+	if got := entries[0].Output; len(got) != maxOutputSize || !strings.HasPrefix(got, "[output truncated]\n") || !strings.HasSuffix(got, "error at the end\n") {
+		t.Errorf("got output of length %v, want truncated output of length %v", len(got), maxOutputSize)
+	}
+}
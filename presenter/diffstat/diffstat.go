@@ -0,0 +1,60 @@
+// Package diffstat provides an enricher that fills in the diffstat of updates
+// from local git repositories.
+package diffstat
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"sort"
+	"strconv"
+
+	"github.com/shurcooL/Go-Package-Store/presenter"
+)
+
+// NewEnricher returns an enricher that fills in Presentation.Diffstat
+// from the local git repository, when it wasn't filled in by a presenter
+// or is incomplete. Both local and remote revisions must be available
+// in the local repository, otherwise the repo is skipped.
+func NewEnricher() presenter.Enricher {
+	return func(ctx context.Context, repo presenter.Repo, p *presenter.Presentation) {
+		if p.Diffstat != nil && !p.Diffstat.Incomplete {
+			// The presenter's diffstat is complete, so there's nothing to add.
+			return
+		}
+		if repo.Path == "" {
+			return
+		}
+		cmd := exec.CommandContext(ctx, "git", "diff", "--numstat", "-z", "--no-renames", repo.LocalRevision, repo.RemoteRevision)
+		cmd.Dir = repo.Path
+		out, err := cmd.Output()
+		if err != nil {
+			// Not a git repository, or revisions are not available locally.
+			return
+		}
+		p.Diffstat = parseNumstat(out)
+	}
+}
+
+// parseNumstat parses the output of "git diff --numstat -z --no-renames".
+// Each file is described by "<additions>\t<deletions>\t<path>\x00",
+// where additions and deletions are "-" for binary files.
+// Files are sorted by path.
+func parseNumstat(out []byte) *presenter.Diffstat {
+	d := &presenter.Diffstat{}
+	for _, line := range bytes.Split(out, []byte{0}) {
+		fields := bytes.SplitN(line, []byte{'\t'}, 3)
+		if len(fields) != 3 {
+			continue
+		}
+		additions, _ := strconv.Atoi(string(fields[0]))
+		deletions, _ := strconv.Atoi(string(fields[1]))
+		d.Files = append(d.Files, presenter.FileChange{
+			Path:      string(fields[2]),
+			Additions: additions,
+			Deletions: deletions,
+		})
+	}
+	sort.Slice(d.Files, func(i, j int) bool { return d.Files[i].Path < d.Files[j].Path })
+	return d
+}
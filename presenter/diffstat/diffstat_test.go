@@ -0,0 +1,30 @@
+package diffstat
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/shurcooL/Go-Package-Store/presenter"
+)
+
+func TestParseNumstat(t *testing.T) {
+	out := []byte("10\t2\tREADME.md\x00" +
+		"0\t35\tcmd/foo/main.go\x00" +
+		"-\t-\tlogo.png\x00" +
+		"1\t1\tpath with\ttab.go\x00")
+	got := parseNumstat(out)
+	want := &presenter.Diffstat{
+		Files: []presenter.FileChange{
+			{Path: "README.md", Additions: 10, Deletions: 2},
+			{Path: "cmd/foo/main.go", Additions: 0, Deletions: 35},
+			{Path: "logo.png"},
+			{Path: "path with\ttab.go", Additions: 1, Deletions: 1},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if additions, deletions := got.Totals(); additions != 11 || deletions != 38 {
+		t.Errorf("got totals +%v -%v, want +11 -38", additions, deletions)
+	}
+}
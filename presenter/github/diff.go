@@ -0,0 +1,98 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-github/github"
+	"github.com/shurcooL/Go-Package-Store/presenter"
+)
+
+// NewDiffstatEnricher returns a GitHub API-powered enricher that fills in Presentation.Diffstat
+// of repos on GitHub, when it wasn't filled in by a presenter or an earlier enricher.
+// httpClient is the HTTP client to be used by the enricher for accessing the GitHub API.
+// If httpClient is nil, then http.DefaultClient is used.
+func NewDiffstatEnricher(httpClient *http.Client) presenter.Enricher {
+	gh := github.NewClient(httpClient)
+	gh.UserAgent = "github.com/shurcooL/Go-Package-Store/presenter/github"
+
+	return func(ctx context.Context, repo presenter.Repo, p *presenter.Presentation) {
+		if p.Diffstat != nil {
+			return
+		}
+		ghOwner, ghRepo, ok := gitHubOwnerRepo(repo)
+		if !ok {
+			return
+		}
+		cc, resp, err := gh.Repositories.CompareCommits(ctx, ghOwner, ghRepo, repo.LocalRevision, repo.RemoteRevision)
+		setRateLimit(p, resp)
+		if rateLimitErr, ok := err.(*github.RateLimitError); ok {
+			setFirstError(p, rateLimitError(rateLimitErr))
+			return
+		} else if err != nil {
+			// Diffstat is optional, so leave it unknown.
+			return
+		}
+		p.Diffstat = extractDiffstat(cc.Files)
+	}
+}
+
+// Diff returns the unified diff between local and remote revisions of repo, using GitHub API.
+// It returns ok false if repo is not on GitHub.
+// httpClient is the HTTP client to be used for accessing the GitHub API.
+// If httpClient is nil, then http.DefaultClient is used.
+func Diff(ctx context.Context, httpClient *http.Client, repo presenter.Repo) (diff []byte, ok bool, err error) {
+	ghOwner, ghRepo, ok := gitHubOwnerRepo(repo)
+	if !ok {
+		return nil, false, nil
+	}
+	gh := github.NewClient(httpClient)
+	gh.UserAgent = "github.com/shurcooL/Go-Package-Store/presenter/github"
+
+	u := fmt.Sprintf("repos/%v/%v/compare/%v...%v", ghOwner, ghRepo, repo.LocalRevision, repo.RemoteRevision)
+	req, err := gh.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, true, err
+	}
+	req.Header.Set("Accept", "application/vnd.github.v3.diff")
+	var buf bytes.Buffer
+	_, err = gh.Do(ctx, req, &buf)
+	if rateLimitErr, ok := err.(*github.RateLimitError); ok {
+		return nil, true, rateLimitError(rateLimitErr)
+	} else if err != nil {
+		return nil, true, err
+	}
+	return buf.Bytes(), true, nil
+}
+
+// CompareFork compares revision of a fork with upstreamRevision of the upstream repository
+// of Go packages under root, at upstreamURL, using GitHub API. It returns how many upstream
+// commits revision doesn't contain. It returns ok false if the upstream isn't on GitHub,
+// or if GitHub doesn't know revision, e.g., because the fork isn't on GitHub.
+// httpClient is the HTTP client to be used for accessing the GitHub API.
+// If httpClient is nil, then http.DefaultClient is used.
+func CompareFork(ctx context.Context, httpClient *http.Client, root, upstreamURL, revision, upstreamRevision string) (behind int, ok bool, err error) {
+	ghOwner, ghRepo, ok := gitHubOwnerRepo(presenter.Repo{Root: root, RepoURL: upstreamURL})
+	if !ok {
+		return 0, false, nil
+	}
+	gh := github.NewClient(httpClient)
+	gh.UserAgent = "github.com/shurcooL/Go-Package-Store/presenter/github"
+
+	cc, resp, err := gh.Repositories.CompareCommits(ctx, ghOwner, ghRepo, revision, upstreamRevision)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		// Revision isn't in the upstream's network of forks.
+		return 0, false, nil
+	}
+	if rateLimitErr, ok := err.(*github.RateLimitError); ok {
+		return 0, false, rateLimitError(rateLimitErr)
+	} else if err != nil {
+		return 0, false, err
+	}
+	if cc.AheadBy == nil {
+		return 0, false, nil
+	}
+	return *cc.AheadBy, true, nil
+}
@@ -1,13 +1,16 @@
-// Package github provides a GitHub API-powered presenter. It supports repositories that are on github.com.
+// Package github provides GitHub API-powered presenters. It supports repositories that are on github.com.
 package github
 
 import (
 	"context"
 	"fmt"
 	"net/http"
+	"os"
+	"os/exec"
+	"sort"
 	"strings"
+	"time"
 
-	"github.com/dustin/go-humanize"
 	"github.com/google/go-github/github"
 	"github.com/shurcooL/Go-Package-Store/presenter"
 )
@@ -20,39 +23,49 @@ func NewPresenter(httpClient *http.Client) presenter.Presenter {
 	gh.UserAgent = "github.com/shurcooL/Go-Package-Store/presenter/github"
 
 	return func(ctx context.Context, repo presenter.Repo) *presenter.Presentation {
-		switch {
-		// Import path begins with "github.com/".
-		case strings.HasPrefix(repo.Root, "github.com/"):
-			elems := strings.Split(repo.Root, "/")
-			if len(elems) != 3 {
-				return nil
-			}
-			return presentGitHubRepo(ctx, gh, repo, elems[1], elems[2])
-		// gopkg.in package.
-		case strings.HasPrefix(repo.Root, "gopkg.in/"):
-			githubOwner, githubRepo, err := gopkgInImportPathToGitHub(repo.Root)
-			if err != nil {
-				return nil
-			}
-			return presentGitHubRepo(ctx, gh, repo, githubOwner, githubRepo)
-		// Underlying GitHub remote.
-		case strings.HasPrefix(repo.RepoURL, "https://github.com/"):
-			elems := strings.Split(strings.TrimSuffix(repo.RepoURL[len("https://"):], ".git"), "/")
-			if len(elems) != 3 {
-				return nil
-			}
-			return presentGitHubRepo(ctx, gh, repo, elems[1], elems[2])
-		// Go repo remote has a GitHub mirror repo.
-		case strings.HasPrefix(repo.RepoURL, "https://go.googlesource.com/"):
-			repoName := repo.RepoURL[len("https://go.googlesource.com/"):]
-			return presentGitHubRepo(ctx, gh, repo, "golang", repoName)
-		// upspin.io.
-		case strings.HasPrefix(repo.RepoURL, "https://upspin.googlesource.com/"):
-			repoName := repo.RepoURL[len("https://upspin.googlesource.com/"):]
-			return presentGitHubRepo(ctx, gh, repo, "upspin", repoName)
-		default:
+		ghOwner, ghRepo, ok := gitHubOwnerRepo(repo)
+		if !ok {
 			return nil
 		}
+		return presentGitHubRepo(ctx, gh, repo, ghOwner, ghRepo)
+	}
+}
+
+// gitHubOwnerRepo returns the owner and name of the GitHub repository
+// that corresponds to repo, or ok false if there isn't one.
+func gitHubOwnerRepo(repo presenter.Repo) (ghOwner, ghRepo string, ok bool) {
+	switch {
+	// Import path begins with "github.com/".
+	case strings.HasPrefix(repo.Root, "github.com/"):
+		elems := strings.Split(repo.Root, "/")
+		if len(elems) != 3 {
+			return "", "", false
+		}
+		return elems[1], elems[2], true
+	// gopkg.in package.
+	case strings.HasPrefix(repo.Root, "gopkg.in/"):
+		githubOwner, githubRepo, err := gopkgInImportPathToGitHub(repo.Root)
+		if err != nil {
+			return "", "", false
+		}
+		return githubOwner, githubRepo, true
+	// Underlying GitHub remote.
+	case strings.HasPrefix(repo.RepoURL, "https://github.com/"):
+		elems := strings.Split(strings.TrimSuffix(repo.RepoURL[len("https://"):], ".git"), "/")
+		if len(elems) != 3 {
+			return "", "", false
+		}
+		return elems[1], elems[2], true
+	// Go repo remote has a GitHub mirror repo.
+	case strings.HasPrefix(repo.RepoURL, "https://go.googlesource.com/"):
+		repoName := repo.RepoURL[len("https://go.googlesource.com/"):]
+		return "golang", repoName, true
+	// upspin.io.
+	case strings.HasPrefix(repo.RepoURL, "https://upspin.googlesource.com/"):
+		repoName := repo.RepoURL[len("https://upspin.googlesource.com/"):]
+		return "upspin", repoName, true
+	default:
+		return "", "", false
 	}
 }
 
@@ -63,19 +76,58 @@ func presentGitHubRepo(ctx context.Context, gh *github.Client, repo presenter.Re
 	}
 
 	// This might take a while.
-	if cc, _, err := gh.Repositories.CompareCommits(ctx, ghOwner, ghRepo, repo.LocalRevision, repo.RemoteRevision); err == nil {
-		p.Changes = extractChanges(cc)
+	var commits []*github.RepositoryCommit // Commits in the update, starting with the most recent.
+	cc, resp, err := gh.Repositories.CompareCommits(ctx, ghOwner, ghRepo, repo.LocalRevision, repo.RemoteRevision)
+	setRateLimit(p, resp)
+	if err == nil {
+		for i := range cc.Commits {
+			commits = append(commits, &cc.Commits[len(cc.Commits)-1-i]) // Reverse order.
+		}
+		if cc.TotalCommits != nil {
+			p.TotalChanges = *cc.TotalCommits
+		}
+		p.Diffstat = extractDiffstat(cc.Files)
 	} else if rateLimitErr, ok := err.(*github.RateLimitError); ok {
-		setFirstError(p, rateLimitError{rateLimitErr})
+		setFirstError(p, rateLimitError(rateLimitErr))
 	} else {
 		setFirstError(p, fmt.Errorf("gh.Repositories.CompareCommits: %v", err))
 	}
 
+	// GitHub caps the list of commits in a comparison at 250 commits.
+	// If it was truncated, page through the commit list of remote revision instead.
+	if p.TotalChanges > len(commits) {
+		cs, resp, err := listCommits(ctx, gh, ghOwner, ghRepo, repo.LocalRevision, repo.RemoteRevision)
+		setRateLimit(p, resp)
+		if err == nil {
+			commits = cs
+		} else if rateLimitErr, ok := err.(*github.RateLimitError); ok {
+			setFirstError(p, rateLimitError(rateLimitErr))
+		} else {
+			setFirstError(p, fmt.Errorf("gh.Repositories.ListCommits: %v", err))
+		}
+	}
+	p.Changes = extractChanges(commits)
+
+	// Find releases and tags crossed by the update.
+	if len(commits) > 0 {
+		releases, resp, err := listReleases(ctx, gh, ghOwner, ghRepo, commits)
+		setRateLimit(p, resp)
+		if err == nil {
+			p.Releases = releases
+		} else if rateLimitErr, ok := err.(*github.RateLimitError); ok {
+			setFirstError(p, rateLimitError(rateLimitErr))
+		} else {
+			setFirstError(p, fmt.Errorf("listReleases: %v", err))
+		}
+	}
+
 	// Use the repo owner avatar image.
-	if repo, _, err := gh.Repositories.Get(ctx, ghOwner, ghRepo); err == nil && repo.Owner != nil && repo.Owner.AvatarURL != nil {
-		p.ImageURL = *repo.Owner.AvatarURL
+	ghRepository, resp, err := gh.Repositories.Get(ctx, ghOwner, ghRepo)
+	setRateLimit(p, resp)
+	if err == nil && ghRepository.Owner != nil && ghRepository.Owner.AvatarURL != nil {
+		p.ImageURL = *ghRepository.Owner.AvatarURL
 	} else if rateLimitErr, ok := err.(*github.RateLimitError); ok {
-		setFirstError(p, rateLimitError{rateLimitErr})
+		setFirstError(p, rateLimitError(rateLimitErr))
 	} else {
 		setFirstError(p, fmt.Errorf("gh.Repositories.Get: %v", err))
 	}
@@ -83,39 +135,284 @@ func presentGitHubRepo(ctx context.Context, gh *github.Client, repo presenter.Re
 	return p
 }
 
-func extractChanges(cc *github.CommitsComparison) []presenter.Change {
-	var cs []presenter.Change
-	for i := range cc.Commits {
-		c := cc.Commits[len(cc.Commits)-1-i] // Reverse order.
-		change := presenter.Change{
-			Message: firstParagraph(*c.Commit.Message),
-			URL:     *c.HTMLURL,
+// maxComparedFiles is the maximum number of files GitHub lists in a comparison.
+const maxComparedFiles = 300
+
+// extractDiffstat returns a diffstat of files changed in a comparison.
+func extractDiffstat(files []github.CommitFile) *presenter.Diffstat {
+	d := &presenter.Diffstat{
+		Incomplete: len(files) >= maxComparedFiles,
+	}
+	for _, f := range files {
+		if f.Filename == nil {
+			continue
+		}
+		fc := presenter.FileChange{Path: *f.Filename}
+		if f.Additions != nil {
+			fc.Additions = *f.Additions
 		}
-		if commentCount := c.Commit.CommentCount; commentCount != nil && *commentCount > 0 {
-			change.Comments.Count = *commentCount
-			change.Comments.URL = *c.HTMLURL + "#comments"
+		if f.Deletions != nil {
+			fc.Deletions = *f.Deletions
 		}
-		cs = append(cs, change)
+		d.Files = append(d.Files, fc)
+	}
+	sort.Slice(d.Files, func(i, j int) bool { return d.Files[i].Path < d.Files[j].Path })
+	return d
+}
+
+func extractChanges(commits []*github.RepositoryCommit) []presenter.Change {
+	var cs []presenter.Change
+	for _, c := range commits {
+		cs = append(cs, extractChange(c))
 	}
 	return cs
 }
 
-// firstParagraph returns the first paragraph of text s.
-func firstParagraph(s string) string {
+// maxListedCommits is the maximum number of commits that listCommits fetches.
+// It limits how many API requests are made for very large updates.
+const maxListedCommits = 1000
+
+// listCommits lists commits from head back to base, starting with the most recent,
+// by paging through the commit list of head. It stops once base is reached,
+// or after maxListedCommits commits. It returns the last API response, if any.
+func listCommits(ctx context.Context, gh *github.Client, ghOwner, ghRepo, base, head string) ([]*github.RepositoryCommit, *github.Response, error) {
+	var cs []*github.RepositoryCommit
+	opt := &github.CommitsListOptions{
+		SHA:         head,
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	for {
+		commits, resp, err := gh.Repositories.ListCommits(ctx, ghOwner, ghRepo, opt)
+		if err != nil {
+			return nil, resp, err
+		}
+		for _, c := range commits {
+			if *c.SHA == base || len(cs) == maxListedCommits {
+				return cs, resp, nil
+			}
+			cs = append(cs, c)
+		}
+		if resp.NextPage == 0 {
+			return cs, resp, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
+
+// listReleases lists releases and tags that point to any of commits,
+// starting with the most recent. Tags are listed with git ls-remote, which
+// doesn't use the API rate limit, falling back to the latest 100 tags via API.
+// Releases are listed via API only if any tags are crossed, and only the latest
+// 100 releases of the repository are considered. It returns the last API response, if any.
+func listReleases(ctx context.Context, gh *github.Client, ghOwner, ghRepo string, commits []*github.RepositoryCommit) ([]presenter.Release, *github.Response, error) {
+	var resp *github.Response
+	tagsByCommit, err := remoteTags(ctx, fmt.Sprintf("https://github.com/%s/%s", ghOwner, ghRepo))
+	if err != nil {
+		tagsByCommit, resp, err = listTags(ctx, gh, ghOwner, ghRepo)
+		if err != nil {
+			return nil, resp, err
+		}
+	}
+	rs := crossedTags(ghOwner, ghRepo, commits, tagsByCommit)
+	if len(rs) == 0 {
+		return nil, resp, nil
+	}
+
+	// Fill in details from releases of tags, if any.
+	releases, resp, err := listReleaseNotes(ctx, gh, ghOwner, ghRepo)
+	if err != nil {
+		return nil, resp, err
+	}
+	fillReleases(rs, releases)
+	return rs, resp, nil
+}
+
+// remoteTags lists tags of git repository at url with git ls-remote.
+// The returned map key is commit SHA, value is names of tags that point to it.
+func remoteTags(ctx context.Context, url string) (map[string][]string, error) {
+	cmd := exec.CommandContext(ctx, "git", "ls-remote", "--tags", url)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0") // Don't prompt for credentials of private repos.
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git ls-remote: %v", err)
+	}
+	return parseRemoteTags(out), nil
+}
+
+// parseRemoteTags parses the output of "git ls-remote --tags".
+// Annotated tags are listed twice, the second time peeled to the commit
+// they point to with a "^{}" suffix, which takes precedence.
+func parseRemoteTags(out []byte) map[string][]string {
+	commits := make(map[string]string) // Tag name -> commit SHA.
+	var names []string
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || !strings.HasPrefix(fields[1], "refs/tags/") {
+			continue
+		}
+		name := strings.TrimPrefix(fields[1], "refs/tags/")
+		if strings.HasSuffix(name, "^{}") {
+			commits[strings.TrimSuffix(name, "^{}")] = fields[0]
+			continue
+		}
+		if _, ok := commits[name]; !ok {
+			names = append(names, name)
+			commits[name] = fields[0]
+		}
+	}
+	tagsByCommit := make(map[string][]string)
+	for _, name := range names {
+		tagsByCommit[commits[name]] = append(tagsByCommit[commits[name]], name)
+	}
+	return tagsByCommit
+}
+
+// listTags lists the latest 100 tags of the repository via API.
+// The returned map key is commit SHA, value is names of tags that point to it.
+func listTags(ctx context.Context, gh *github.Client, ghOwner, ghRepo string) (map[string][]string, *github.Response, error) {
+	tags, resp, err := gh.Repositories.ListTags(ctx, ghOwner, ghRepo, &github.ListOptions{PerPage: 100})
+	if err != nil {
+		return nil, resp, err
+	}
+	tagsByCommit := make(map[string][]string)
+	for _, t := range tags {
+		if t.Name == nil || t.Commit == nil || t.Commit.SHA == nil {
+			continue
+		}
+		tagsByCommit[*t.Commit.SHA] = append(tagsByCommit[*t.Commit.SHA], *t.Name)
+	}
+	return tagsByCommit, resp, nil
+}
+
+// crossedTags returns tags that point to any of commits, starting with the most recent.
+func crossedTags(ghOwner, ghRepo string, commits []*github.RepositoryCommit, tagsByCommit map[string][]string) []presenter.Release {
+	var rs []presenter.Release
+	for _, c := range commits {
+		for _, tag := range tagsByCommit[*c.SHA] {
+			r := presenter.Release{
+				Tag:  tag,
+				Name: tag,
+				URL:  fmt.Sprintf("https://github.com/%s/%s/releases/tag/%s", ghOwner, ghRepo, tag),
+			}
+			if c.Commit.Committer != nil && c.Commit.Committer.Date != nil {
+				r.Date = *c.Commit.Committer.Date
+			}
+			rs = append(rs, r)
+		}
+	}
+	return rs
+}
+
+// releaseNotes is a GitHub release, with its body rendered as HTML.
+type releaseNotes struct {
+	TagName     string    `json:"tag_name"`
+	Name        string    `json:"name"`
+	BodyHTML    string    `json:"body_html"`
+	Draft       bool      `json:"draft"`
+	PublishedAt time.Time `json:"published_at"`
+	HTMLURL     string    `json:"html_url"`
+}
+
+// listReleaseNotes lists the latest 100 releases of the repository,
+// with their bodies rendered as HTML by GitHub.
+func listReleaseNotes(ctx context.Context, gh *github.Client, ghOwner, ghRepo string) ([]releaseNotes, *github.Response, error) {
+	req, err := gh.NewRequest("GET", fmt.Sprintf("repos/%v/%v/releases?per_page=100", ghOwner, ghRepo), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github.v3.html+json")
+	var releases []releaseNotes
+	resp, err := gh.Do(ctx, req, &releases)
+	if err != nil {
+		return nil, resp, err
+	}
+	return releases, resp, nil
+}
+
+// fillReleases fills in details of tags in rs from their releases, if any.
+func fillReleases(rs []presenter.Release, releases []releaseNotes) {
+	releasesByTag := make(map[string]releaseNotes)
+	for _, r := range releases {
+		if r.Draft {
+			continue
+		}
+		releasesByTag[r.TagName] = r
+	}
+	for i := range rs {
+		release, ok := releasesByTag[rs[i].Tag]
+		if !ok {
+			continue
+		}
+		if release.Name != "" {
+			rs[i].Name = release.Name
+		}
+		rs[i].Body = release.BodyHTML
+		if !release.PublishedAt.IsZero() {
+			rs[i].Date = release.PublishedAt
+		}
+		if release.HTMLURL != "" {
+			rs[i].URL = release.HTMLURL
+		}
+	}
+}
+
+func extractChange(c *github.RepositoryCommit) presenter.Change {
+	message, body := splitMessage(*c.Commit.Message)
+	change := presenter.Change{
+		Message: message,
+		Body:    body,
+		URL:     *c.HTMLURL,
+	}
+	if a := c.Commit.Author; a != nil {
+		if a.Name != nil {
+			change.Author.Name = *a.Name
+		}
+		if a.Date != nil {
+			change.Time = *a.Date
+		}
+	}
+	if a := c.Author; a != nil && a.AvatarURL != nil {
+		change.Author.AvatarURL = *a.AvatarURL
+	}
+	if commentCount := c.Commit.CommentCount; commentCount != nil && *commentCount > 0 {
+		change.Comments.Count = *commentCount
+		change.Comments.URL = *c.HTMLURL + "#comments"
+	}
+	return change
+}
+
+// splitMessage splits commit message s into its first paragraph and the rest.
+func splitMessage(s string) (firstParagraph, rest string) {
 	i := strings.Index(s, "\n\n")
 	if i == -1 {
-		return s
+		return strings.TrimSpace(s), ""
 	}
-	return s[:i]
+	return s[:i], strings.TrimSpace(s[i+len("\n\n"):])
 }
 
-// rateLimitError is an error presentation wrapper for consistent display of *github.RateLimitError.
-type rateLimitError struct {
-	err *github.RateLimitError
+// rateLimitError converts err into a *presenter.RateLimitError, for consistent display
+// and so that the pipeline can present the repo again once the rate limit resets.
+func rateLimitError(err *github.RateLimitError) *presenter.RateLimitError {
+	return &presenter.RateLimitError{
+		RateLimit: presenter.RateLimit{
+			Service:   "GitHub API",
+			Remaining: err.Rate.Remaining,
+			Reset:     err.Rate.Reset.Time,
+		},
+		Hint: "but you can set GO_PACKAGE_STORE_GITHUB_TOKEN env var for higher rate limit",
+	}
 }
 
-func (r rateLimitError) Error() string {
-	return fmt.Sprintf("GitHub API rate limit exceeded; it will be reset in %v (but you can set GO_PACKAGE_STORE_GITHUB_TOKEN env var for higher rate limit)", humanize.Time(r.err.Rate.Reset.Time))
+// setRateLimit sets the rate limit of p from resp, if resp has one.
+func setRateLimit(p *presenter.Presentation, resp *github.Response) {
+	if resp == nil || resp.Rate.Limit == 0 {
+		return
+	}
+	p.RateLimit = &presenter.RateLimit{
+		Service:   "GitHub API",
+		Remaining: resp.Rate.Remaining,
+		Reset:     resp.Rate.Reset.Time,
+	}
 }
 
 // setFirstError sets error if it's the first one. It does nothing otherwise.
@@ -0,0 +1,57 @@
+package github
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/github"
+	"github.com/shurcooL/Go-Package-Store/presenter"
+)
+
+func TestParseRemoteTags(t *testing.T) {
+	out := []byte(`c1	refs/tags/v1.0.0
+t2	refs/tags/v1.1.0
+c2	refs/tags/v1.1.0^{}
+c2	refs/tags/v1.1.0-rc.1
+c3	refs/heads/master
+`)
+	got := parseRemoteTags(out)
+	want := map[string][]string{
+		"c1": {"v1.0.0"},
+		"c2": {"v1.1.0", "v1.1.0-rc.1"}, // Annotated tag v1.1.0 is peeled to commit c2.
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestCrossedTagsAndReleases(t *testing.T) {
+	date := func(day int) *time.Time {
+		t := time.Date(2017, time.February, day, 10, 0, 0, 0, time.UTC)
+		return &t
+	}
+	commit := func(sha string, day int) *github.RepositoryCommit {
+		return &github.RepositoryCommit{SHA: &sha, Commit: &github.Commit{Committer: &github.CommitAuthor{Date: date(day)}}}
+	}
+	commits := []*github.RepositoryCommit{commit("c3", 3), commit("c2", 2)} // Update from c1 to c3.
+	tagsByCommit := map[string][]string{
+		"c1": {"v1.0.0"}, // Not crossed.
+		"c2": {"v1.1.0"},
+		"c3": {"v1.2.0"},
+	}
+
+	rs := crossedTags("owner", "repo", commits, tagsByCommit)
+	fillReleases(rs, []releaseNotes{
+		{TagName: "v1.2.0", Name: "Second minor", BodyHTML: "<p>Notes.</p>", PublishedAt: *date(4), HTMLURL: "https://github.com/owner/repo/releases/v1.2.0"},
+		{TagName: "v1.1.0", Name: "Draft", Draft: true},
+		{TagName: "v1.0.0", Name: "First"},
+	})
+	want := []presenter.Release{
+		{Tag: "v1.2.0", Name: "Second minor", Date: *date(4), Body: "<p>Notes.</p>", URL: "https://github.com/owner/repo/releases/v1.2.0"},
+		{Tag: "v1.1.0", Name: "v1.1.0", Date: *date(2), URL: "https://github.com/owner/repo/releases/tag/v1.1.0"},
+	}
+	if !reflect.DeepEqual(rs, want) {
+		t.Errorf("got %+v, want %+v", rs, want)
+	}
+}
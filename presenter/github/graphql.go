@@ -0,0 +1,394 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shurcooL/Go-Package-Store/presenter"
+	"github.com/shurcooL/githubv4"
+)
+
+// NewBatchPresenter returns a GitHub GraphQL API-powered batch presenter.
+// It fetches owner avatars, commit history, tags and releases of many repositories
+// in a single GraphQL query, which uses far less of the API rate limit than
+// the presenter returned by NewPresenter. Repositories it can't present
+// (e.g., because the local revision is too far behind) are left for other presenters.
+//
+// httpClient is the HTTP client to be used by the presenter for accessing the GitHub API.
+// GitHub GraphQL API requires authentication, so httpClient is expected to provide it.
+// If httpClient is nil, then http.DefaultClient is used.
+func NewBatchPresenter(httpClient *http.Client) presenter.BatchPresenter {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return func(ctx context.Context, rs []presenter.Repo) []*presenter.Presentation {
+		ps := make([]*presenter.Presentation, len(rs))
+
+		var batch []batchRepo
+		for i, repo := range rs {
+			ghOwner, ghRepo, ok := gitHubOwnerRepo(repo)
+			if !ok {
+				continue
+			}
+			batch = append(batch, batchRepo{Index: i, Repo: repo, Owner: ghOwner, Name: ghRepo})
+		}
+		if len(batch) == 0 {
+			return ps
+		}
+
+		rl := &rateLimitRecorder{base: httpClient.Transport}
+		client := *httpClient
+		client.Transport = rl
+		gh := githubv4.NewClient(&client)
+
+		// This might take a while.
+		repositories, err := queryBatch(ctx, gh, batch)
+		switch rate := rl.RateLimit(); {
+		case err != nil && (isRateLimited(err) || rate != nil && rate.Remaining == 0):
+			// Present the repos again once the rate limit resets.
+			rlErr := &presenter.RateLimitError{RateLimit: presenter.RateLimit{Service: "GitHub GraphQL API", Reset: time.Now().Add(time.Hour)}}
+			if rate != nil {
+				rlErr.RateLimit = *rate
+			}
+			for _, r := range batch {
+				ps[r.Index] = &presenter.Presentation{
+					HomeURL:  "https://" + r.Repo.Root,
+					ImageURL: "https://github.com/images/gravatars/gravatar-user-420.png",
+					Error:    rlErr,
+				}
+			}
+			return ps
+		case err != nil && repositories == nil:
+			// Leave all repos to other presenters; they'll report errors in more detail.
+			log.Println("github batch presenter:", err)
+			return ps
+		}
+		for i, r := range batch {
+			p := presentBatchRepo(ctx, gh, r, repositories[i])
+			if p == nil {
+				continue
+			}
+			p.RateLimit = rl.RateLimit()
+			ps[r.Index] = p
+		}
+		return ps
+	}
+}
+
+// batchRepo is a GitHub repository in a batch query.
+type batchRepo struct {
+	Index int // Index of Repo in the input of batch presenter.
+	Repo  presenter.Repo
+	Owner string // GitHub repository owner.
+	Name  string // GitHub repository name.
+}
+
+// batchAlias returns the GraphQL field alias used for i-th repository in a batch query.
+func batchAlias(i int) string { return fmt.Sprintf("r%d", i) }
+
+// batchRepository is the result of a batch query for a single repository.
+type batchRepository struct {
+	Owner struct {
+		AvatarURL string
+	}
+	Object *struct {
+		Commit struct {
+			History batchHistory `graphql:"history(first: 100)"`
+		} `graphql:"... on Commit"`
+	}
+	Refs struct {
+		Nodes []batchTag
+	} `graphql:"refs(refPrefix: \"refs/tags/\", first: 100, orderBy: {field: TAG_COMMIT_DATE, direction: DESC})"`
+	Releases struct {
+		Nodes []batchRelease
+	} `graphql:"releases(first: 100, orderBy: {field: CREATED_AT, direction: DESC})"`
+}
+
+type batchHistory struct {
+	Nodes    []batchCommit
+	PageInfo struct {
+		EndCursor   string
+		HasNextPage bool
+	}
+}
+
+type batchCommit struct {
+	OID           string `graphql:"oid"`
+	Message       string
+	URL           string
+	CommittedDate time.Time
+	Author        struct {
+		Name      string
+		Date      time.Time
+		AvatarURL string
+	}
+	Comments struct {
+		TotalCount int
+	}
+}
+
+// batchTag is a tag ref. Target is either a commit, or an annotated tag that points to one.
+type batchTag struct {
+	Name   string
+	Target struct {
+		OID string `graphql:"oid"`
+		Tag struct {
+			Target struct {
+				OID string `graphql:"oid"`
+			}
+		} `graphql:"... on Tag"`
+	}
+}
+
+type batchRelease struct {
+	TagName         string
+	Name            string
+	DescriptionHTML string `graphql:"descriptionHTML"`
+	PublishedAt     time.Time
+	URL             string
+	IsDraft         bool
+}
+
+// queryBatch queries the repositories of batch in a single query, returning them
+// in the same order. Repositories that weren't found are nil. If err is non-nil,
+// but repositories isn't, then the query partially succeeded.
+func queryBatch(ctx context.Context, gh *githubv4.Client, batch []batchRepo) (repositories []*batchRepository, err error) {
+	// The query has a repository field per repo, so construct its type dynamically.
+	// Each field is of a batchRepository-like type, with the revision variable
+	// of the repo in its object field.
+	variables := make(map[string]interface{})
+	var fields []reflect.StructField
+	for i, r := range batch {
+		variables[fmt.Sprintf("owner%d", i)] = githubv4.String(r.Owner)
+		variables[fmt.Sprintf("name%d", i)] = githubv4.String(r.Name)
+		variables[fmt.Sprintf("rev%d", i)] = githubv4.String(r.Repo.RemoteRevision)
+		fields = append(fields, reflect.StructField{
+			Name: strings.ToUpper(batchAlias(i)),
+			Type: reflect.PtrTo(batchRepositoryType(fmt.Sprintf("$rev%d", i))),
+			Tag:  reflect.StructTag(fmt.Sprintf(`graphql:"%s: repository(owner: $owner%d, name: $name%d)"`, batchAlias(i), i, i)),
+		})
+	}
+	q := reflect.New(reflect.StructOf(fields))
+	err = gh.Query(ctx, q.Interface(), variables)
+	repositories = make([]*batchRepository, len(batch))
+	found := false
+	for i := range batch {
+		if f := q.Elem().Field(i); !f.IsNil() {
+			repositories[i] = f.Convert(reflect.TypeOf((*batchRepository)(nil))).Interface().(*batchRepository)
+			found = true
+		}
+	}
+	if err != nil && !found {
+		return nil, err
+	}
+	return repositories, err
+}
+
+// batchRepositoryType returns a type that is batchRepository, except that
+// its object field is queried with the specified revision expression.
+// Values of the returned type are convertible to batchRepository.
+func batchRepositoryType(expression string) reflect.Type {
+	t := reflect.TypeOf(batchRepository{})
+	var fields []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Name == "Object" {
+			f.Tag = reflect.StructTag(fmt.Sprintf(`graphql:"object(expression: %s)"`, expression))
+		}
+		fields = append(fields, f)
+	}
+	return reflect.StructOf(fields)
+}
+
+// historyPageQuery queries a page of commit history after cursor.
+type historyPageQuery struct {
+	Repository struct {
+		Object *struct {
+			Commit struct {
+				History batchHistory `graphql:"history(first: 100, after: $cursor)"`
+			} `graphql:"... on Commit"`
+		} `graphql:"object(expression: $rev)"`
+	} `graphql:"repository(owner: $owner, name: $name)"`
+}
+
+// queryHistoryPage queries the page of commit history of r's remote revision after cursor.
+func queryHistoryPage(ctx context.Context, gh *githubv4.Client, r batchRepo, cursor string) (batchHistory, error) {
+	var q historyPageQuery
+	err := gh.Query(ctx, &q, map[string]interface{}{
+		"owner":  githubv4.String(r.Owner),
+		"name":   githubv4.String(r.Name),
+		"rev":    githubv4.String(r.Repo.RemoteRevision),
+		"cursor": githubv4.String(cursor),
+	})
+	if err != nil {
+		return batchHistory{}, err
+	}
+	if q.Repository.Object == nil {
+		return batchHistory{}, fmt.Errorf("revision %v not found", r.Repo.RemoteRevision)
+	}
+	return q.Repository.Object.Commit.History, nil
+}
+
+// presentBatchRepo returns a presentation of r using repository,
+// or nil if there isn't enough information to present it.
+// If the local revision isn't within the first page of history,
+// further pages are queried, up to maxListedCommits commits.
+func presentBatchRepo(ctx context.Context, gh *githubv4.Client, r batchRepo, repository *batchRepository) *presenter.Presentation {
+	if repository == nil || repository.Object == nil {
+		return nil
+	}
+
+	var commits []batchCommit // Commits in the update, starting with the most recent.
+	history := repository.Object.Commit.History
+	for foundLocal := false; !foundLocal; {
+		for _, c := range history.Nodes {
+			if c.OID == r.Repo.LocalRevision {
+				foundLocal = true
+				break
+			}
+			commits = append(commits, c)
+		}
+		if foundLocal {
+			break
+		}
+		if !history.PageInfo.HasNextPage || len(commits) >= maxListedCommits {
+			// Local revision is not within fetched history, so we can't tell
+			// which changes are new. Leave it for other presenters.
+			return nil
+		}
+		// This might take a while.
+		var err error
+		history, err = queryHistoryPage(ctx, gh, r, history.PageInfo.EndCursor)
+		if err != nil {
+			log.Println("github batch presenter:", err)
+			return nil
+		}
+	}
+
+	p := &presenter.Presentation{
+		HomeURL:  "https://" + r.Repo.Root,
+		ImageURL: "https://github.com/images/gravatars/gravatar-user-420.png", // Default fallback.
+		Releases: batchReleases(r, commits, repository.Refs.Nodes, repository.Releases.Nodes),
+	}
+	if repository.Owner.AvatarURL != "" {
+		p.ImageURL = repository.Owner.AvatarURL
+	}
+	for _, c := range commits {
+		message, body := splitMessage(c.Message)
+		change := presenter.Change{
+			Message: message,
+			Body:    body,
+			Author: presenter.Author{
+				Name:      c.Author.Name,
+				AvatarURL: c.Author.AvatarURL,
+			},
+			Time: c.Author.Date,
+			URL:  c.URL,
+		}
+		if c.Comments.TotalCount > 0 {
+			change.Comments.Count = c.Comments.TotalCount
+			change.Comments.URL = c.URL + "#comments"
+		}
+		p.Changes = append(p.Changes, change)
+	}
+	return p
+}
+
+// batchReleases returns releases and tags that point to any of commits of r,
+// starting with the most recent. Details of tags are filled in from releases.
+func batchReleases(r batchRepo, commits []batchCommit, tags []batchTag, releases []batchRelease) []presenter.Release {
+	tagsByCommit := make(map[string][]string) // Commit SHA -> tag names.
+	for _, t := range tags {
+		commit := t.Target.OID
+		if t.Target.Tag.Target.OID != "" {
+			// Annotated tag.
+			commit = t.Target.Tag.Target.OID
+		}
+		tagsByCommit[commit] = append(tagsByCommit[commit], t.Name)
+	}
+	releasesByTag := make(map[string]batchRelease)
+	for _, release := range releases {
+		if release.IsDraft {
+			continue
+		}
+		releasesByTag[release.TagName] = release
+	}
+
+	var rs []presenter.Release
+	for _, c := range commits {
+		for _, tag := range tagsByCommit[c.OID] {
+			release := presenter.Release{
+				Tag:  tag,
+				Name: tag,
+				Date: c.CommittedDate,
+				URL:  fmt.Sprintf("https://github.com/%s/%s/releases/tag/%s", r.Owner, r.Name, tag),
+			}
+			if gr, ok := releasesByTag[tag]; ok {
+				if gr.Name != "" {
+					release.Name = gr.Name
+				}
+				release.Body = gr.DescriptionHTML
+				release.Date = gr.PublishedAt
+				release.URL = gr.URL
+			}
+			rs = append(rs, release)
+		}
+	}
+	return rs
+}
+
+// isRateLimited reports whether err is a GitHub GraphQL API rate limit error.
+func isRateLimited(err error) bool {
+	return strings.Contains(strings.ToLower(err.Error()), "rate limit")
+}
+
+// rateLimitRecorder is an http.RoundTripper that records the rate limit
+// reported by the latest GitHub API response.
+type rateLimitRecorder struct {
+	base http.RoundTripper // If nil, http.DefaultTransport is used.
+
+	mu   sync.Mutex
+	rate *presenter.RateLimit
+}
+
+func (t *rateLimitRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	remaining, err1 := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	reset, err2 := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if err1 == nil && err2 == nil {
+		t.mu.Lock()
+		t.rate = &presenter.RateLimit{
+			Service:   "GitHub GraphQL API",
+			Remaining: remaining,
+			Reset:     time.Unix(reset, 0),
+		}
+		t.mu.Unlock()
+	}
+	return resp, nil
+}
+
+// RateLimit returns the latest recorded rate limit, or nil if none.
+func (t *rateLimitRecorder) RateLimit() *presenter.RateLimit {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.rate == nil {
+		return nil
+	}
+	rate := *t.rate
+	return &rate
+}
@@ -0,0 +1,154 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shurcooL/Go-Package-Store/presenter"
+)
+
+func TestBatchPresenter(t *testing.T) {
+	client := &http.Client{
+		Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			var in struct {
+				Query     string
+				Variables map[string]string
+			}
+			if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+				t.Fatal(err)
+			}
+			header := http.Header{
+				"X-Ratelimit-Remaining": {"4000"},
+				"X-Ratelimit-Reset":     {"1500000000"},
+			}
+			if strings.Contains(in.Query, "$cursor") {
+				// Next page of history of go-yaml/yaml.
+				if got, want := in.Variables, map[string]string{
+					"owner": "go-yaml", "name": "yaml", "rev": "e2", "cursor": "page2",
+				}; !reflect.DeepEqual(got, want) {
+					t.Errorf("got variables %v, want %v", got, want)
+				}
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     header,
+					Body: ioutil.NopCloser(strings.NewReader(`{"data": {"repository": {"object": {"history": {"nodes": [
+	{"oid": "e1", "message": "On second page.", "url": "https://github.com/go-yaml/yaml/commit/e1", "comments": {"totalCount": 0}},
+	{"oid": "d1", "message": "Local.", "url": "https://github.com/go-yaml/yaml/commit/d1", "comments": {"totalCount": 0}}
+], "pageInfo": {"endCursor": "page3", "hasNextPage": true}}}}}}`)),
+				}, nil
+			}
+			if got, want := in.Variables, map[string]string{
+				"owner0": "owner", "name0": "repo", "rev0": "c3",
+				"owner1": "go-yaml", "name1": "yaml", "rev1": "e2",
+			}; !reflect.DeepEqual(got, want) {
+				t.Errorf("got variables %v, want %v", got, want)
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     header,
+				Body: ioutil.NopCloser(strings.NewReader(`{"data": {
+	"r0": {"owner": {"avatarUrl": "https://avatars.example.com/owner"}, "object": {"history": {"nodes": [
+		{"oid": "c3", "message": "Third.\n\nMore details.", "url": "https://github.com/owner/repo/commit/c3", "committedDate": "2017-02-26T11:00:00Z", "author": {"name": "Gopher", "date": "2017-02-26T10:00:00Z", "avatarUrl": "https://avatars.example.com/gopher"}, "comments": {"totalCount": 2}},
+		{"oid": "c2", "message": "Second.", "url": "https://github.com/owner/repo/commit/c2", "committedDate": "2017-02-25T10:00:00Z", "comments": {"totalCount": 0}},
+		{"oid": "c1", "message": "First.", "url": "https://github.com/owner/repo/commit/c1", "comments": {"totalCount": 0}}
+	], "pageInfo": {"endCursor": "x", "hasNextPage": true}}},
+	"refs": {"nodes": [
+		{"name": "v1.1.0", "target": {"oid": "t3", "target": {"oid": "c3"}}},
+		{"name": "v1.0.1", "target": {"oid": "c2"}},
+		{"name": "v1.0.0", "target": {"oid": "c1"}}
+	]},
+	"releases": {"nodes": [
+		{"tagName": "v1.1.0", "name": "Big release", "descriptionHTML": "<p>Release <em>notes</em>.</p>", "publishedAt": "2017-02-27T10:00:00Z", "url": "https://github.com/owner/repo/releases/tag/v1.1.0", "isDraft": false},
+		{"tagName": "v1.0.1", "name": "Draft", "isDraft": true}
+	]}},
+	"r1": {"owner": {"avatarUrl": ""}, "object": {"history": {"nodes": [
+		{"oid": "e2", "message": "On first page.", "url": "https://github.com/go-yaml/yaml/commit/e2", "comments": {"totalCount": 0}}
+	], "pageInfo": {"endCursor": "page2", "hasNextPage": true}}}, "refs": {"nodes": []}, "releases": {"nodes": []}}
+}}`)),
+			}, nil
+		}),
+	}
+
+	bp := NewBatchPresenter(client)
+	got := bp(context.Background(), []presenter.Repo{
+		{Root: "github.com/owner/repo", RepoURL: "https://github.com/owner/repo", LocalRevision: "c1", RemoteRevision: "c3"},
+		{Root: "example.com/other", RepoURL: "https://example.com/other", LocalRevision: "a", RemoteRevision: "b"},
+		{Root: "gopkg.in/yaml.v2", RepoURL: "https://gopkg.in/yaml.v2", LocalRevision: "d1", RemoteRevision: "e2"},
+	})
+	rateLimit := &presenter.RateLimit{Service: "GitHub GraphQL API", Remaining: 4000, Reset: time.Unix(1500000000, 0)}
+	want := []*presenter.Presentation{
+		{
+			HomeURL:  "https://github.com/owner/repo",
+			ImageURL: "https://avatars.example.com/owner",
+			Changes: []presenter.Change{
+				{
+					Message:  "Third.",
+					Body:     "More details.",
+					Author:   presenter.Author{Name: "Gopher", AvatarURL: "https://avatars.example.com/gopher"},
+					Time:     time.Date(2017, time.February, 26, 10, 0, 0, 0, time.UTC),
+					URL:      "https://github.com/owner/repo/commit/c3",
+					Comments: presenter.Comments{Count: 2, URL: "https://github.com/owner/repo/commit/c3#comments"},
+				},
+				{Message: "Second.", URL: "https://github.com/owner/repo/commit/c2"},
+			},
+			Releases: []presenter.Release{
+				{Tag: "v1.1.0", Name: "Big release", Date: time.Date(2017, time.February, 27, 10, 0, 0, 0, time.UTC), Body: "<p>Release <em>notes</em>.</p>", URL: "https://github.com/owner/repo/releases/tag/v1.1.0"},
+				{Tag: "v1.0.1", Name: "v1.0.1", Date: time.Date(2017, time.February, 25, 10, 0, 0, 0, time.UTC), URL: "https://github.com/owner/repo/releases/tag/v1.0.1"},
+			},
+			RateLimit: rateLimit,
+		},
+		nil, // Not a GitHub repo.
+		{
+			HomeURL:  "https://gopkg.in/yaml.v2",
+			ImageURL: "https://github.com/images/gravatars/gravatar-user-420.png",
+			Changes: []presenter.Change{
+				{Message: "On first page.", URL: "https://github.com/go-yaml/yaml/commit/e2"},
+				{Message: "On second page.", URL: "https://github.com/go-yaml/yaml/commit/e1"},
+			},
+			RateLimit: rateLimit,
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestBatchPresenterRateLimited(t *testing.T) {
+	client := &http.Client{
+		Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header: http.Header{
+					"X-Ratelimit-Remaining": {"0"},
+					"X-Ratelimit-Reset":     {"1500000000"},
+				},
+				Body: ioutil.NopCloser(strings.NewReader(`{"data": null, "errors": [{"type": "RATE_LIMITED", "message": "API rate limit exceeded"}]}`)),
+			}, nil
+		}),
+	}
+
+	bp := NewBatchPresenter(client)
+	got := bp(context.Background(), []presenter.Repo{
+		{Root: "github.com/owner/repo", RepoURL: "https://github.com/owner/repo", LocalRevision: "c1", RemoteRevision: "c3"},
+	})
+	if len(got) != 1 || got[0] == nil {
+		t.Fatalf("got %v, want a presentation", got)
+	}
+	err, ok := got[0].Error.(*presenter.RateLimitError)
+	if !ok {
+		t.Fatalf("got error %v, want a rate limit error", got[0].Error)
+	}
+	if want := time.Unix(1500000000, 0); !err.Reset.Equal(want) {
+		t.Errorf("got reset %v, want %v", err.Reset, want)
+	}
+}
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
@@ -0,0 +1,57 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-github/github"
+	"github.com/shurcooL/Go-Package-Store/presenter"
+	"github.com/shurcooL/Go-Package-Store/presenter/license"
+)
+
+// NewLicenseFetcher returns a GitHub API-powered license fetcher.
+// httpClient is the HTTP client to be used by the fetcher for accessing the GitHub API.
+// If httpClient is nil, then http.DefaultClient is used.
+func NewLicenseFetcher(httpClient *http.Client) license.Fetcher {
+	gh := github.NewClient(httpClient)
+	gh.UserAgent = "github.com/shurcooL/Go-Package-Store/presenter/github"
+
+	return func(ctx context.Context, repo presenter.Repo, rev string) (text []byte, ok bool, err error) {
+		ghOwner, ghRepo, ok := gitHubOwnerRepo(repo)
+		if !ok {
+			return nil, false, nil
+		}
+		opt := &github.RepositoryContentGetOptions{Ref: rev}
+		_, dir, _, err := gh.Repositories.GetContents(ctx, ghOwner, ghRepo, "", opt)
+		if err != nil {
+			return nil, false, contentsError(err)
+		}
+		for _, f := range dir {
+			if f.Type == nil || *f.Type != "file" || f.Name == nil || !license.IsLicenseFile(*f.Name) {
+				continue
+			}
+			fc, _, _, err := gh.Repositories.GetContents(ctx, ghOwner, ghRepo, *f.Path, opt)
+			if err != nil {
+				return nil, false, contentsError(err)
+			} else if fc == nil {
+				return nil, false, fmt.Errorf("%v is not a file", *f.Path)
+			}
+			content, err := fc.GetContent()
+			if err != nil {
+				return nil, false, err
+			}
+			return []byte(content), true, nil
+		}
+		return nil, true, nil
+	}
+}
+
+// contentsError returns the error of getting repository contents via GitHub API,
+// converting rate limit errors into *presenter.RateLimitError.
+func contentsError(err error) error {
+	if rateLimitErr, ok := err.(*github.RateLimitError); ok {
+		return rateLimitError(rateLimitErr)
+	}
+	return err
+}
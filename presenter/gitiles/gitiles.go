@@ -9,6 +9,7 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/shurcooL/Go-Package-Store/presenter"
 )
@@ -85,9 +86,19 @@ type log struct {
 
 type commit struct {
 	Commit  string `json:"commit"`
+	Author  author `json:"author"`
 	Message string `json:"message"`
 }
 
+type author struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Time  string `json:"time"` // E.g., "Fri Jun 24 17:27:31 2016 -0700".
+}
+
+// timeLayout is the layout of author.Time.
+const timeLayout = "Mon Jan 02 15:04:05 2006 -0700"
+
 func extractChanges(repo presenter.Repo, l log) []presenter.Change {
 	// Verify/find Repo.RemoteRevision.
 	log := l.Log
@@ -100,19 +111,26 @@ func extractChanges(repo presenter.Repo, l log) []presenter.Change {
 		if commit.Commit == repo.LocalRevision {
 			break
 		}
-		cs = append(cs, presenter.Change{
-			Message: firstParagraph(commit.Message),
+		message, body := splitMessage(commit.Message)
+		change := presenter.Change{
+			Message: message,
+			Body:    body,
+			Author:  presenter.Author{Name: commit.Author.Name},
 			URL:     repo.RepoURL + "/+/" + commit.Commit + "%5e%21",
-		})
+		}
+		if t, err := time.Parse(timeLayout, commit.Author.Time); err == nil {
+			change.Time = t
+		}
+		cs = append(cs, change)
 	}
 	return cs
 }
 
-// firstParagraph returns the first paragraph of text s.
-func firstParagraph(s string) string {
+// splitMessage splits commit message s into its first paragraph and the rest.
+func splitMessage(s string) (firstParagraph, rest string) {
 	i := strings.Index(s, "\n\n")
 	if i == -1 {
-		return s
+		return strings.TrimSpace(s), ""
 	}
-	return s[:i]
+	return s[:i], strings.TrimSpace(s[i+len("\n\n"):])
 }
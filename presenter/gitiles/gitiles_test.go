@@ -5,7 +5,11 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
+	"time"
+
+	"github.com/shurcooL/Go-Package-Store/presenter"
 )
 
 func TestFetchLog(t *testing.T) {
@@ -32,7 +36,12 @@ func TestFetchLog(t *testing.T) {
 		t.Fatalf("got %q, want %q", got, want)
 	}
 	if got, want := log.Log[0], (commit{
-		Commit:  "7a1b48a03240285fcdb91f7890f647cd90358f84",
+		Commit: "7a1b48a03240285fcdb91f7890f647cd90358f84",
+		Author: author{
+			Name:  "Chris Broadfoot",
+			Email: "cbro@golang.org",
+			Time:  "Fri Jun 24 17:27:31 2016 -0700",
+		},
 		Message: "google-api-go-client: update all APIs\n\nChange-Id: If682f3b0bcf992351f82763cd76561c7c30466a5\nReviewed-on: https://code-review.googlesource.com/5051\nReviewed-by: Brad Fitzpatrick \u003cbradfitz@golang.org\u003e\n",
 	}); got != want {
 		t.Errorf("got %q, want %q", got, want)
@@ -45,3 +54,35 @@ func TestFetchLog(t *testing.T) {
 type roundTripperFunc func(req *http.Request) (*http.Response, error)
 
 func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestExtractChanges(t *testing.T) {
+	l := log{Log: []commit{
+		{
+			Commit:  "c3",
+			Author:  author{Name: "Gopher", Time: "Fri Jun 24 17:27:31 2016 -0700"},
+			Message: "all: do a thing\n\nLonger description.\n\nChange-Id: I0\n",
+		},
+		{Commit: "c2", Message: "all: do another thing\n"},
+		{Commit: "c1", Message: "all: initial commit\n"},
+	}}
+	repo := presenter.Repo{RepoURL: "https://code.googlesource.com/repo", LocalRevision: "c2", RemoteRevision: "c3"}
+
+	got := extractChanges(repo, l)
+	want := []presenter.Change{{
+		Message: "all: do a thing",
+		Body:    "Longer description.\n\nChange-Id: I0",
+		Author:  presenter.Author{Name: "Gopher"},
+		Time:    time.Date(2016, time.June, 25, 0, 27, 31, 0, time.UTC),
+		URL:     "https://code.googlesource.com/repo/+/c3%5e%21",
+	}}
+	if len(got) != len(want) {
+		t.Fatalf("got %d changes, want %d", len(got), len(want))
+	}
+	if !got[0].Time.Equal(want[0].Time) {
+		t.Errorf("got time %v, want %v", got[0].Time, want[0].Time)
+	}
+	got[0].Time = want[0].Time
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,150 @@
+// Package license provides an enricher that detects license changes across updates.
+package license
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/shurcooL/Go-Package-Store/presenter"
+)
+
+// Fetcher fetches the license file of repository r at revision rev.
+// It returns ok false if it can't tell, e.g., because r isn't hosted
+// by a service it supports, or because fetching failed with a non-nil error.
+// Reaching a remote API rate limit is reported as a *presenter.RateLimitError.
+// Nil text with ok true means there's no license file.
+type Fetcher func(ctx context.Context, r presenter.Repo, rev string) (text []byte, ok bool, err error)
+
+// NewEnricher returns an enricher that sets Presentation.LicenseChange
+// when the license type of a repo changes across an update.
+//
+// License files are read from the local git repository when the revision
+// is available there. Otherwise, fetchers are consulted in order.
+// If the license can't be determined at either revision, the license type
+// there is Unknown, and LicenseChange is set too. If a fetcher reaches
+// a remote API rate limit, Presentation.Error is set to the *presenter.RateLimitError,
+// so that the repo is presented again once the rate limit resets.
+func NewEnricher(fetchers ...Fetcher) presenter.Enricher {
+	fetchers = append([]Fetcher{fetchLocal}, fetchers...)
+
+	return func(ctx context.Context, repo presenter.Repo, p *presenter.Presentation) {
+		from := classify(ctx, fetchers, repo, repo.LocalRevision, p)
+		to := classify(ctx, fetchers, repo, repo.RemoteRevision, p)
+		if from != to || from == Unknown {
+			p.LicenseChange = &presenter.LicenseChange{From: from, To: to}
+		}
+	}
+}
+
+// classify returns the license type of repo at revision rev, or Unknown if
+// fetchers can't tell. A rate limit error is reported via p.Error, unless it's already set.
+func classify(ctx context.Context, fetchers []Fetcher, repo presenter.Repo, rev string, p *presenter.Presentation) string {
+	for _, f := range fetchers {
+		text, ok, err := f(ctx, repo, rev)
+		if rateLimitErr, isRateLimit := err.(*presenter.RateLimitError); isRateLimit && p.Error == nil {
+			p.Error = rateLimitErr
+		}
+		if ok {
+			return Classify(text)
+		}
+	}
+	return Unknown
+}
+
+// fetchLocal fetches the license file from the local git repository of r.
+func fetchLocal(ctx context.Context, r presenter.Repo, rev string) (text []byte, ok bool, err error) {
+	if r.Path == "" {
+		return nil, false, nil
+	}
+	git := func(args ...string) ([]byte, error) {
+		cmd := exec.CommandContext(ctx, "git", args...)
+		cmd.Dir = r.Path
+		return cmd.Output()
+	}
+	// List files at the root of the repository, rather than at r.Path.
+	names, err := git("ls-tree", "--name-only", "--full-tree", rev)
+	if err != nil {
+		// Not a git repository, or rev is not available locally.
+		return nil, false, nil
+	}
+	for _, name := range strings.Split(string(names), "\n") {
+		if !IsLicenseFile(name) {
+			continue
+		}
+		text, err := git("show", rev+":"+name)
+		if err != nil {
+			return nil, false, err
+		}
+		return text, true, nil
+	}
+	return nil, true, nil
+}
+
+// IsLicenseFile reports whether a file with specified name
+// at the root of a repository is expected to contain its license.
+func IsLicenseFile(name string) bool {
+	return licenseFile.MatchString(name)
+}
+
+var licenseFile = regexp.MustCompile(`^(?i)(LICEN[CS]E|COPYING)([-_][A-Z0-9-]+)?(\.(md|markdown|txt|rst))?$`)
+
+// Types of licenses returned by Classify that aren't SPDX license identifiers.
+const (
+	None    = "None"    // No license file.
+	Other   = "Other"   // Unrecognized license.
+	Unknown = "Unknown" // License file couldn't be fetched, so the license type isn't known.
+)
+
+// Classify returns the SPDX identifier of the license in text,
+// None if text is nil, or Other if the license is not recognized.
+func Classify(text []byte) string {
+	if text == nil {
+		return None
+	}
+	// Normalize case, whitespace and punctuation that varies across copies of the same license.
+	t := strings.Join(strings.Fields(string(bytes.ToLower(text))), " ")
+	t = strings.NewReplacer("\"", "", "'", "", "“", "", "”", "").Replace(t)
+	for _, l := range licenses {
+		if matchAll(t, l.Phrases) {
+			return l.ID
+		}
+	}
+	return Other
+}
+
+// licenses are recognized licenses, in order of matching.
+// More specific licenses come before licenses whose text they contain.
+var licenses = []struct {
+	ID      string
+	Phrases []string // All of these phrases must be present in normalized text.
+}{
+	{"AGPL-3.0", []string{"gnu affero general public license"}},
+	{"LGPL-3.0", []string{"gnu lesser general public license", "version 3"}},
+	{"LGPL-2.1", []string{"gnu lesser general public license"}},
+	{"LGPL-2.0", []string{"gnu library general public license"}},
+	{"GPL-3.0", []string{"gnu general public license", "version 3"}},
+	{"GPL-2.0", []string{"gnu general public license"}},
+	{"MPL-2.0", []string{"mozilla public license", "2.0"}},
+	{"EPL-2.0", []string{"eclipse public license", "2.0"}},
+	{"EPL-1.0", []string{"eclipse public license"}},
+	{"Apache-2.0", []string{"apache license", "version 2.0"}},
+	{"Unlicense", []string{"this is free and unencumbered software released into the public domain"}},
+	{"CC0-1.0", []string{"cc0 1.0 universal"}},
+	{"WTFPL", []string{"do what the fuck you want to public license"}},
+	{"MIT", []string{"permission is hereby granted, free of charge"}},
+	{"ISC", []string{"permission to use, copy, modify, and", "distribute this software for any purpose with or without fee is hereby granted"}},
+	{"BSD-3-Clause", []string{"redistribution and use in source and binary forms", "endorse or promote products"}},
+	{"BSD-2-Clause", []string{"redistribution and use in source and binary forms"}},
+}
+
+func matchAll(t string, phrases []string) bool {
+	for _, p := range phrases {
+		if !strings.Contains(t, p) {
+			return false
+		}
+	}
+	return true
+}
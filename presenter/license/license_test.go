@@ -0,0 +1,134 @@
+package license
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/shurcooL/Go-Package-Store/presenter"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"", None},
+		{`Copyright (c) 2009 The Go Authors. All rights reserved.
+
+Redistribution and use in source and binary forms, with or without
+modification, are permitted provided that the following conditions are
+met:
+   * Neither the name of Google Inc. nor the names of its
+contributors may be used to endorse or promote products derived from
+this software without specific prior written permission.`, "BSD-3-Clause"},
+		{`MIT License
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software")`, "MIT"},
+		{`                    GNU AFFERO GENERAL PUBLIC LICENSE
+                       Version 3, 19 November 2007`, "AGPL-3.0"},
+		{`                    GNU GENERAL PUBLIC LICENSE
+                       Version 3, 29 June 2007`, "GPL-3.0"},
+		{`                    GNU LESSER GENERAL PUBLIC LICENSE
+                       Version 3, 29 June 2007`, "LGPL-3.0"},
+		{`                                 Apache License
+                           Version 2.0, January 2004`, "Apache-2.0"},
+		{`Permission to use, copy, modify, and/or distribute this software for any
+purpose with or without fee is hereby granted`, "ISC"},
+		{"All rights reserved.", Other},
+	}
+	for _, tc := range tests {
+		var text []byte
+		if tc.text != "" {
+			text = []byte(tc.text)
+		}
+		if got := Classify(text); got != tc.want {
+			t.Errorf("Classify(%.40q): got %q, want %q", tc.text, got, tc.want)
+		}
+	}
+}
+
+func TestIsLicenseFile(t *testing.T) {
+	for name, want := range map[string]bool{
+		"LICENSE":         true,
+		"LICENSE.md":      true,
+		"License.txt":     true,
+		"COPYING":         true,
+		"LICENSE-MIT":     true,
+		"LICENCE":         true,
+		"license.go":      false,
+		"README.md":       false,
+		"LICENSE_test.go": false,
+	} {
+		if got := IsLicenseFile(name); got != want {
+			t.Errorf("IsLicenseFile(%q): got %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestEnricher(t *testing.T) {
+	rateLimitErr := &presenter.RateLimitError{RateLimit: presenter.RateLimit{Service: "GitHub API"}}
+	mit := []byte("Permission is hereby granted, free of charge, to any person obtaining a copy of this software. " +
+		"THE SOFTWARE IS PROVIDED \"AS IS\", WITHOUT WARRANTY OF ANY KIND.")
+	tests := []struct {
+		name    string
+		fetch   Fetcher
+		want    *presenter.LicenseChange
+		wantErr error
+	}{
+		{
+			name: "unchanged",
+			fetch: func(context.Context, presenter.Repo, string) ([]byte, bool, error) {
+				return mit, true, nil
+			},
+			want: nil,
+		},
+		{
+			name: "changed",
+			fetch: func(_ context.Context, _ presenter.Repo, rev string) ([]byte, bool, error) {
+				if rev == "local" {
+					return mit, true, nil
+				}
+				return nil, true, nil
+			},
+			want: &presenter.LicenseChange{From: Classify(mit), To: None},
+		},
+		{
+			name: "not supported",
+			fetch: func(context.Context, presenter.Repo, string) ([]byte, bool, error) {
+				return nil, false, nil
+			},
+			want: &presenter.LicenseChange{From: Unknown, To: Unknown},
+		},
+		{
+			name: "error",
+			fetch: func(_ context.Context, _ presenter.Repo, rev string) ([]byte, bool, error) {
+				if rev == "local" {
+					return mit, true, nil
+				}
+				return nil, false, errors.New("not found")
+			},
+			want: &presenter.LicenseChange{From: Classify(mit), To: Unknown},
+		},
+		{
+			name: "rate limited",
+			fetch: func(context.Context, presenter.Repo, string) ([]byte, bool, error) {
+				return nil, false, rateLimitErr
+			},
+			want:    &presenter.LicenseChange{From: Unknown, To: Unknown},
+			wantErr: rateLimitErr,
+		},
+	}
+	for _, tc := range tests {
+		var p presenter.Presentation
+		NewEnricher(tc.fetch)(context.Background(), presenter.Repo{Root: "example.com/repo", LocalRevision: "local", RemoteRevision: "remote"}, &p)
+		if !reflect.DeepEqual(p.LicenseChange, tc.want) {
+			t.Errorf("%s: got license change %+v, want %+v", tc.name, p.LicenseChange, tc.want)
+		}
+		if p.Error != tc.wantErr {
+			t.Errorf("%s: got error %v, want %v", tc.name, p.Error, tc.wantErr)
+		}
+	}
+}
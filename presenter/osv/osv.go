@@ -0,0 +1,203 @@
+// Package osv provides an enricher that flags known vulnerabilities fixed by updates,
+// using a vulnerability database in OSV format (https://ossf.github.io/osv-schema/)
+// stored in a local directory, such as a mirror of https://vuln.go.dev.
+package osv
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/shurcooL/Go-Package-Store/presenter"
+)
+
+// NewEnricher returns an enricher that adds advisories for known vulnerabilities
+// that affect the local revision of a repo and are fixed in its remote revision.
+// The vulnerability database is read from .json files in dir and its subdirectories,
+// once, when NewEnricher is called. Only entries for the Go ecosystem are used.
+//
+// Revisions are mapped to versions via tags in the local git repository,
+// so repos without a local git repository are skipped.
+func NewEnricher(dir string) (presenter.Enricher, error) {
+	db, err := load(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context, repo presenter.Repo, p *presenter.Presentation) {
+		if repo.Path == "" {
+			return
+		}
+		entries := db.entries(repo.Root)
+		if len(entries) == 0 {
+			return
+		}
+
+		local := revision{dir: repo.Path, rev: repo.LocalRevision}
+		remote := revision{dir: repo.Path, rev: repo.RemoteRevision}
+		if local.version() != "" {
+			// The remote revision is at least as new as the newest release crossed by the update.
+			remote.releases = p.Releases
+			remote.minVersion = local.version()
+		}
+		for _, e := range entries {
+			if e.affects(repo.Root, &local) && !e.affects(repo.Root, &remote) {
+				p.Advisories = append(p.Advisories, e.advisory())
+			}
+		}
+	}, nil
+}
+
+// database maps Go package or module paths to entries that affect them.
+type database map[string][]*entry
+
+// entries returns entries that affect Go packages in repository with specified root,
+// sorted by ID. Entries that affect multiple packages in the repository are returned once.
+func (db database) entries(root string) []*entry {
+	var entries []*entry
+	seen := make(map[string]bool) // Set of entry IDs.
+	for name, es := range db {
+		if name != root && !strings.HasPrefix(name, root+"/") {
+			continue
+		}
+		for _, e := range es {
+			if seen[e.ID] {
+				continue
+			}
+			seen[e.ID] = true
+			entries = append(entries, e)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+	return entries
+}
+
+// load loads entries from .json files in dir and its subdirectories.
+// Files that aren't OSV entries, such as database indexes, are skipped.
+func load(dir string) (database, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, err
+	}
+	db := make(database)
+	err := filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() || filepath.Ext(path) != ".json" {
+			return nil
+		}
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var e entry
+		if err := json.Unmarshal(b, &e); err != nil || e.ID == "" {
+			// Not an OSV entry.
+			return nil
+		}
+		seen := make(map[string]bool)
+		for _, a := range e.Affected {
+			if a.Package.Ecosystem != "Go" || seen[a.Package.Name] {
+				continue
+			}
+			seen[a.Package.Name] = true
+			db[a.Package.Name] = append(db[a.Package.Name], &e)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("loading vulnerability database: %v", err)
+	}
+	return db, nil
+}
+
+// entry is an OSV vulnerability entry.
+type entry struct {
+	ID         string     `json:"id"`
+	Summary    string     `json:"summary"`
+	Details    string     `json:"details"`
+	Aliases    []string   `json:"aliases"`
+	Affected   []affected `json:"affected"`
+	References []struct {
+		Type string `json:"type"`
+		URL  string `json:"url"`
+	} `json:"references"`
+	DatabaseSpecific struct {
+		URL string `json:"url"`
+	} `json:"database_specific"`
+}
+
+type affected struct {
+	Package struct {
+		Ecosystem string `json:"ecosystem"`
+		Name      string `json:"name"`
+	} `json:"package"`
+	Ranges   []affectedRange `json:"ranges"`
+	Versions []string        `json:"versions"`
+}
+
+type affectedRange struct {
+	Type   string  `json:"type"` // "SEMVER", "ECOSYSTEM" or "GIT".
+	Events []event `json:"events"`
+}
+
+type event struct {
+	Introduced   string `json:"introduced"`
+	Fixed        string `json:"fixed"`
+	LastAffected string `json:"last_affected"`
+}
+
+// affects reports whether e affects revision r of repository with specified root.
+func (e *entry) affects(root string, r *revision) bool {
+	for _, a := range e.Affected {
+		if a.Package.Ecosystem != "Go" ||
+			(a.Package.Name != root && !strings.HasPrefix(a.Package.Name, root+"/")) {
+			continue
+		}
+		for _, v := range a.Versions {
+			if r.version() != "" && compareVersions(r.version(), v) == 0 {
+				return true
+			}
+		}
+		for _, ar := range a.Ranges {
+			switch ar.Type {
+			case "SEMVER", "ECOSYSTEM":
+				if v := r.version(); v != "" && versionAffected(ar.Events, v) {
+					return true
+				}
+			case "GIT":
+				if r.gitAffected(ar.Events) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// advisory returns a presenter advisory for e.
+func (e *entry) advisory() presenter.Advisory {
+	a := presenter.Advisory{
+		ID:      e.ID,
+		Aliases: e.Aliases,
+		Summary: e.Summary,
+		URL:     e.DatabaseSpecific.URL,
+	}
+	if a.Summary == "" {
+		a.Summary = strings.SplitN(strings.TrimSpace(e.Details), "\n", 2)[0]
+	}
+	for _, r := range e.References {
+		if a.URL != "" {
+			break
+		}
+		if r.Type == "ADVISORY" || r.Type == "WEB" {
+			a.URL = r.URL
+		}
+	}
+	return a
+}
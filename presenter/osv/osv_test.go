@@ -0,0 +1,145 @@
+package osv
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/shurcooL/Go-Package-Store/presenter"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"1.2.3", "1.2.4", -1},
+		{"1.10.0", "1.9.0", +1},
+		{"0", "0.0.1", -1},
+		{"1.2", "1.2.0", 0},
+		{"1.2.3-rc.1", "1.2.3", -1},
+		{"1.2.3-rc.2", "1.2.3-rc.10", -1},
+		{"1.2.3-alpha", "1.2.3-1", +1},
+		{"1.2.3+build", "1.2.3", 0},
+		{"invalid", "0", -1},
+	}
+	for _, tc := range tests {
+		if got := compareVersions(tc.a, tc.b); got != tc.want {
+			t.Errorf("compareVersions(%q, %q): got %v, want %v", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestVersionAffected(t *testing.T) {
+	events := []event{
+		{Fixed: "1.4.0"},
+		{Introduced: "0"},
+		{Introduced: "2.0.0"},
+		{LastAffected: "2.1.0"},
+	}
+	tests := []struct {
+		v    string
+		want bool
+	}{
+		{"1.0.0", true},
+		{"1.3.9", true},
+		{"1.4.0", false},
+		{"1.9.0", false},
+		{"2.0.0", true},
+		{"2.1.0", true},
+		{"2.1.1", false},
+	}
+	for _, tc := range tests {
+		if got := versionAffected(events, tc.v); got != tc.want {
+			t.Errorf("versionAffected(%q): got %v, want %v", tc.v, got, tc.want)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "osv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"ID/GO-2021-0001.json": `{
+	"id": "GO-2021-0001",
+	"summary": "Denial of service in example.com/foo",
+	"aliases": ["CVE-2021-0001"],
+	"affected": [
+		{"package": {"ecosystem": "Go", "name": "example.com/foo"}, "ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}, {"fixed": "1.2.0"}]}]},
+		{"package": {"ecosystem": "Go", "name": "example.com/foo"}, "ranges": [{"type": "SEMVER", "events": [{"introduced": "2.0.0"}, {"fixed": "2.0.1"}]}]}
+	],
+	"database_specific": {"url": "https://pkg.go.dev/vuln/GO-2021-0001"}
+}`,
+		"ID/PYSEC-2021-0002.json": `{"id": "PYSEC-2021-0002", "affected": [{"package": {"ecosystem": "PyPI", "name": "foo"}}]}`,
+		"index/modules.json":      `[{"path": "example.com/foo"}]`,
+		"README.md":               `Not JSON.`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	db, err := load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(db), 1; got != want {
+		t.Fatalf("got %v packages in database, want %v", got, want)
+	}
+	es := db["example.com/foo"]
+	if got, want := len(es), 1; got != want {
+		t.Fatalf("got %v entries for example.com/foo, want %v", got, want)
+	}
+	if got, want := es[0].advisory(), (presenter.Advisory{
+		ID:      "GO-2021-0001",
+		Aliases: []string{"CVE-2021-0001"},
+		Summary: "Denial of service in example.com/foo",
+		URL:     "https://pkg.go.dev/vuln/GO-2021-0001",
+	}); !reflect.DeepEqual(got, want) {
+		t.Errorf("got advisory %+v, want %+v", got, want)
+	}
+
+	for _, tc := range []struct {
+		version string
+		want    bool
+	}{
+		{"1.1.0", true},
+		{"1.2.0", false},
+		{"2.0.0", true},
+		{"2.0.1", false},
+	} {
+		r := &revision{minVersion: tc.version}
+		r.v = &r.minVersion
+		if got := es[0].affects("example.com/foo", r); got != tc.want {
+			t.Errorf("affects(%q): got %v, want %v", tc.version, got, tc.want)
+		}
+	}
+}
+
+func TestDatabaseEntries(t *testing.T) {
+	e1 := &entry{ID: "GO-2021-0001"}
+	e2 := &entry{ID: "GO-2021-0002"}
+	db := database{
+		"example.com/foo":     {e2, e1},
+		"example.com/foo/bar": {e1},
+		"example.com/foobar":  {&entry{ID: "GO-2021-0003"}},
+	}
+	var got []string
+	for _, e := range db.entries("example.com/foo") {
+		got = append(got, e.ID)
+	}
+	if want := []string{"GO-2021-0001", "GO-2021-0002"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got entries %v, want %v", got, want)
+	}
+}
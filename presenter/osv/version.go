@@ -0,0 +1,221 @@
+package osv
+
+import (
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/shurcooL/Go-Package-Store/presenter"
+)
+
+// revision is a revision of a local git repository.
+type revision struct {
+	dir string // Local filesystem path to the repository.
+	rev string
+
+	// releases and minVersion are optional lower bounds of the version of rev,
+	// used when rev isn't available in the local repository.
+	releases   []presenter.Release
+	minVersion string
+
+	v *string // Cached result of version.
+}
+
+// version returns the semantic version of r, without the "v" prefix,
+// based on the most recent version tag reachable from it in the local repository.
+// It returns empty string if the version can't be determined.
+func (r *revision) version() string {
+	if r.v != nil {
+		return *r.v
+	}
+	var v string
+	if out, err := r.git("describe", "--tags", "--abbrev=0", "--match=v[0-9]*", r.rev); err == nil && isVersion(out) {
+		v = strings.TrimPrefix(out, "v")
+	} else {
+		v = r.minVersion
+		for _, rel := range r.releases {
+			if isVersion(rel.Tag) && (v == "" || compareVersions(strings.TrimPrefix(rel.Tag, "v"), v) > 0) {
+				v = strings.TrimPrefix(rel.Tag, "v")
+			}
+		}
+	}
+	r.v = &v
+	return v
+}
+
+// gitAffected reports whether r is affected according to events of a GIT range,
+// which are expected to be in order. Events with commits that aren't available
+// in the local repository are ignored.
+func (r *revision) gitAffected(events []event) bool {
+	affected := false
+	for _, e := range events {
+		switch {
+		case e.Introduced != "":
+			if e.Introduced == "0" || r.descendsFrom(e.Introduced) {
+				affected = true
+			}
+		case e.Fixed != "":
+			if r.descendsFrom(e.Fixed) {
+				affected = false
+			}
+		case e.LastAffected != "":
+			if e.LastAffected != r.rev && r.descendsFrom(e.LastAffected) {
+				affected = false
+			}
+		}
+	}
+	return affected
+}
+
+// descendsFrom reports whether r is commit or its descendant.
+func (r *revision) descendsFrom(commit string) bool {
+	_, err := r.git("merge-base", "--is-ancestor", commit, r.rev)
+	return err == nil
+}
+
+func (r *revision) git(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = r.dir
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// versionAffected reports whether version v is affected according to events
+// of a SEMVER or ECOSYSTEM range.
+func versionAffected(events []event, v string) bool {
+	events = append([]event(nil), events...)
+	sort.SliceStable(events, func(i, j int) bool {
+		return compareVersions(events[i].version(), events[j].version()) < 0
+	})
+	affected := false
+	for _, e := range events {
+		switch {
+		case e.Introduced != "":
+			if e.Introduced == "0" || compareVersions(v, e.Introduced) >= 0 {
+				affected = true
+			}
+		case e.Fixed != "":
+			if compareVersions(v, e.Fixed) >= 0 {
+				affected = false
+			}
+		case e.LastAffected != "":
+			if compareVersions(v, e.LastAffected) > 0 {
+				affected = false
+			}
+		}
+	}
+	return affected
+}
+
+func (e event) version() string {
+	switch {
+	case e.Introduced != "":
+		return e.Introduced
+	case e.Fixed != "":
+		return e.Fixed
+	default:
+		return e.LastAffected
+	}
+}
+
+// isVersion reports whether tag is a semantic version tag, such as "v1.2.3".
+func isVersion(tag string) bool {
+	if !strings.HasPrefix(tag, "v") {
+		return false
+	}
+	_, ok := parseVersion(strings.TrimPrefix(tag, "v"))
+	return ok
+}
+
+// compareVersions compares semantic versions a and b, without the "v" prefix.
+// It returns -1, 0 or +1 depending on whether a < b, a == b or a > b.
+// Version "0" is less than all other versions, and build metadata is ignored.
+// Invalid versions are considered equal to each other and less than valid ones.
+func compareVersions(a, b string) int {
+	pa, okA := parseVersion(a)
+	pb, okB := parseVersion(b)
+	switch {
+	case !okA && !okB:
+		return 0
+	case !okA:
+		return -1
+	case !okB:
+		return +1
+	}
+	for i := 0; i < 3; i++ {
+		if c := compareInts(pa.numbers[i], pb.numbers[i]); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case pa.prerelease == pb.prerelease:
+		return 0
+	case pa.prerelease == "":
+		return +1
+	case pb.prerelease == "":
+		return -1
+	}
+	idsA, idsB := strings.Split(pa.prerelease, "."), strings.Split(pb.prerelease, ".")
+	for i := 0; i < len(idsA) && i < len(idsB); i++ {
+		if c := comparePrereleaseIDs(idsA[i], idsB[i]); c != 0 {
+			return c
+		}
+	}
+	return compareInts(len(idsA), len(idsB))
+}
+
+type version struct {
+	numbers    [3]int // Major, minor and patch.
+	prerelease string
+}
+
+// parseVersion parses semantic version v, without the "v" prefix.
+// Minor and patch numbers may be omitted, as in "1" or "1.2".
+func parseVersion(v string) (version, bool) {
+	var pv version
+	if i := strings.IndexByte(v, '+'); i >= 0 {
+		v = v[:i] // Ignore build metadata.
+	}
+	if i := strings.IndexByte(v, '-'); i >= 0 {
+		v, pv.prerelease = v[:i], v[i+1:]
+	}
+	parts := strings.Split(v, ".")
+	if len(parts) > 3 {
+		return version{}, false
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return version{}, false
+		}
+		pv.numbers[i] = n
+	}
+	return pv, true
+}
+
+func comparePrereleaseIDs(a, b string) int {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+	switch {
+	case errA == nil && errB == nil:
+		return compareInts(na, nb)
+	case errA == nil:
+		return -1 // Numeric identifiers have lower precedence.
+	case errB == nil:
+		return +1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return +1
+	default:
+		return 0
+	}
+}
@@ -3,11 +3,27 @@ package presenter
 
 import (
 	"context"
+	"fmt"
+	"time"
+
+	"github.com/dustin/go-humanize"
 )
 
 // Presenter returns a Presentation for r, or nil if it can't.
 type Presenter func(ctx context.Context, r Repo) *Presentation
 
+// BatchPresenter returns Presentations for many repos at once.
+// It's useful for presenters that can fetch information for
+// multiple repos more efficiently than one at a time.
+//
+// The returned slice must have the same length as rs.
+// Its i-th element is the Presentation for rs[i], or nil if it can't present it.
+type BatchPresenter func(ctx context.Context, rs []Repo) []*Presentation
+
+// Enricher adds information to the Presentation p of r,
+// regardless of which Presenter created it.
+type Enricher func(ctx context.Context, r Repo, p *Presentation)
+
 // Repo represents a single repository to be presented.
 // It contains the input for a Presenter.
 type Repo struct {
@@ -19,6 +35,10 @@ type Repo struct {
 
 	LocalRevision  string
 	RemoteRevision string
+
+	// Path is the local filesystem path to the repository.
+	// Optional (empty string means there's no local repository).
+	Path string
 }
 
 // Presentation provides information about a Go package repo with an available update.
@@ -28,13 +48,101 @@ type Presentation struct {
 	ImageURL string   // Image representing the Go package, typically its owner.
 	Changes  []Change // List of changes, starting with the most recent.
 	Error    error    // Any error that occurred during presentation, to be displayed to user.
+
+	// Releases is the list of releases and tags crossed by the update,
+	// starting with the most recent. Optional.
+	Releases []Release
+
+	// TotalChanges is the total count of changes in the update.
+	// It may be greater than len(Changes) if not all changes were listed.
+	// Zero means it's not known, and len(Changes) should be used instead.
+	TotalChanges int
+
+	// Diffstat summarizes changes to files in the update.
+	// Optional (nil means not known).
+	Diffstat *Diffstat
+
+	// LicenseChange describes how the license changed across the update.
+	// Optional (nil means it didn't change, or it wasn't checked).
+	LicenseChange *LicenseChange
+
+	// Advisories is the list of known vulnerabilities that affect
+	// the local revision and are fixed in the remote revision. Optional.
+	Advisories []Advisory
+
+	// IncompatibleChanges describes incompatible changes to the exported API
+	// of Go packages in the repository, e.g., "github.com/owner/repo/pkg.Func: removed". Optional.
+	IncompatibleChanges []string
+
+	// RateLimit is the latest known state of the rate limit of the remote API
+	// used by the presenter. Optional (nil means not known or not applicable).
+	RateLimit *RateLimit
 }
 
 // Change represents a single commit message.
 type Change struct {
-	Message  string   // Commit message of this change.
-	URL      string   // URL of this change.
-	Comments Comments // Comments on this change.
+	Message  string    // First paragraph of commit message of this change.
+	Body     string    // Rest of commit message of this change, after the first paragraph. Optional.
+	Author   Author    // Author of this change. Optional.
+	Time     time.Time // Time this change was authored. Zero value means unknown.
+	URL      string    // URL of this change.
+	Comments Comments  // Comments on this change.
+}
+
+// Author represents the author of a change.
+type Author struct {
+	Name      string // Name of the author.
+	AvatarURL string // Avatar image of the author. Optional (empty string means none available).
+}
+
+// Release represents a release or a tag.
+type Release struct {
+	Tag  string    // Tag name.
+	Name string    // Release name. It's the same as Tag if there's no release for the tag.
+	Date time.Time // Release publication date, or tagged commit date. Zero value means unknown.
+	Body string    // Release notes, as HTML rendered and sanitized by the host (e.g., from Markdown). Optional.
+	URL  string    // URL of the release. Optional (empty string means none available).
+}
+
+// Diffstat summarizes changes to files.
+type Diffstat struct {
+	Files []FileChange // Changed files, sorted by path.
+
+	// Incomplete reports whether Files may be missing some of the changed files,
+	// e.g., because a remote API caps the number of files it lists.
+	Incomplete bool
+}
+
+// FileChange represents changes to a single file.
+type FileChange struct {
+	Path      string // Slash-separated path of the file, relative to repository root.
+	Additions int    // Count of added lines.
+	Deletions int    // Count of deleted lines.
+}
+
+// Totals returns total counts of added and deleted lines in all files.
+func (d Diffstat) Totals() (additions, deletions int) {
+	for _, f := range d.Files {
+		additions += f.Additions
+		deletions += f.Deletions
+	}
+	return additions, deletions
+}
+
+// LicenseChange represents a change of license type.
+// License type is "Unknown" at a revision where it couldn't be determined,
+// in which case it's a possible change, even if both are "Unknown".
+type LicenseChange struct {
+	From string // License type at local revision, e.g., "MIT".
+	To   string // License type at remote revision, e.g., "AGPL-3.0".
+}
+
+// Advisory represents a known vulnerability.
+type Advisory struct {
+	ID      string   // Advisory ID, e.g., "GO-2021-0113".
+	Aliases []string // Other IDs of the same vulnerability, e.g., "CVE-2021-38561". Optional.
+	Summary string   // Short description of the vulnerability. Optional.
+	URL     string   // URL with details about the vulnerability. Optional (empty string means none available).
 }
 
 // Comments represents change discussion.
@@ -42,3 +150,26 @@ type Comments struct {
 	Count int    // Count of comments on this change.
 	URL   string // URL of change discussion. Optional (empty string means none available).
 }
+
+// RateLimit describes the state of a remote API rate limit.
+type RateLimit struct {
+	Service   string    // Name of the remote API, e.g., "GitHub API".
+	Remaining int       // Number of requests remaining before the rate limit is reached.
+	Reset     time.Time // Time when the rate limit resets.
+}
+
+// RateLimitError is the Presentation.Error of a presentation that's incomplete
+// because a remote API rate limit was exceeded. Presenting the repo again
+// after the rate limit resets is expected to succeed.
+type RateLimitError struct {
+	RateLimit
+	Hint string // Optional advice on avoiding the rate limit.
+}
+
+func (e *RateLimitError) Error() string {
+	s := fmt.Sprintf("%v rate limit exceeded; it will be reset in %v", e.Service, humanize.Time(e.Reset))
+	if e.Hint != "" {
+		s += " (" + e.Hint + ")"
+	}
+	return s
+}
@@ -1,6 +1,8 @@
 package gps
 
 import (
+	"time"
+
 	"github.com/shurcooL/vcsstate"
 	"golang.org/x/tools/go/vcs"
 )
@@ -34,6 +36,18 @@ type Repo struct {
 		RemoteURL string
 
 		Revision string // Revision of the default branch (not necessarily the checked out one).
+
+		// Branch is the local branch whose upstream is Remote.Branch, when Remote.Tracked is true.
+		// Its name may differ from Remote.Branch. Empty means it's named the same as Remote.Branch.
+		Branch string
+
+		// Status is the status of the working tree if it's dirty, empty if it's clean.
+		// It's only populated if repos with dirty working trees are presented.
+		Status string
+
+		// Diverged reports whether the local default branch has commits that aren't
+		// on the remote default branch. It's only populated if diverged repos are presented.
+		Diverged bool
 	}
 	Remote struct {
 		// RepoURL is the repository URL, including scheme, as determined dynamically from the import path.
@@ -41,9 +55,30 @@ type Repo struct {
 
 		Branch   string // Default branch, as determined from remote. Only populated if VCS or RemoteVCS is non-nil.
 		Revision string // Revision of the default branch.
+
+		// Tracked reports whether Branch is the upstream of the checked out local branch,
+		// rather than the default branch. It's only populated if upstream branches are tracked.
+		Tracked bool
+
+		// Upstream is the canonical upstream repository at RepoURL, if the local repository
+		// is cloned from a fork of it. Branch and Revision are then of the fork.
+		// Nil means it's not a fork. It's only populated if forks are presented.
+		Upstream *Upstream
+
+		// LastKnown is the time the remote state was fetched at, if it's the last-known
+		// remote state from a previous run, because the remote couldn't be reached.
+		// Zero means the remote state is current.
+		LastKnown time.Time
 	}
 }
 
+// Upstream is the canonical upstream repository of a fork.
+type Upstream struct {
+	Branch   string // Default branch of the upstream repository.
+	Revision string // Revision of the default branch.
+	Behind   int    // Number of upstream commits that the fork's remote revision doesn't contain.
+}
+
 // ImportPathPattern returns an import path pattern that matches all of the Go packages in this repo.
 // E.g.:
 //
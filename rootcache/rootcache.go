@@ -0,0 +1,191 @@
+// Package rootcache provides a persistent cache of repository roots
+// of Go packages, as resolved from their import paths.
+//
+// Resolving the repository root of a vanity import path requires fetching
+// a "?go-get=1" page over the network, so caching it across runs saves time.
+package rootcache
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/tools/go/vcs"
+)
+
+// Path returns the path of the cache file in the user cache directory.
+func Path() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "github.com", "shurcooL", "Go-Package-Store", "reporoots.json"), nil
+}
+
+// saveDelay is how long resolved repository roots are batched up before writing the file.
+const saveDelay = time.Second
+
+// Cache is a cache of repository roots stored in a file.
+// It's safe for concurrent use.
+type Cache struct {
+	path string
+	ttl  time.Duration
+
+	// resolve resolves repository roots on cache misses.
+	resolve func(importPath string) (*vcs.RepoRoot, error)
+	// offline is whether expired entries are used, and cache misses aren't resolved.
+	offline bool
+
+	mu     sync.Mutex
+	roots  map[string]entry // Map key is repo root.
+	saving bool             // A save is scheduled.
+}
+
+// entry is a cached repository root.
+type entry struct {
+	VCS  string    // Command of the version control system, e.g., "git".
+	Repo string    // Repository URL, including scheme.
+	Time time.Time // Time the repository root was resolved.
+}
+
+// New returns an empty cache stored in a file at path, whose entries expire after ttl.
+// The file is written shortly after the first repository root is resolved.
+func New(path string, ttl time.Duration) *Cache {
+	return &Cache{
+		path:    path,
+		ttl:     ttl,
+		resolve: func(importPath string) (*vcs.RepoRoot, error) { return vcs.RepoRootForImportPath(importPath, false) },
+		roots:   make(map[string]entry),
+	}
+}
+
+// Open opens the cache stored in a file at path, whose entries expire after ttl.
+// A missing or corrupted file is an empty cache.
+func Open(path string, ttl time.Duration) *Cache {
+	c := New(path, ttl)
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return c
+	}
+	var roots map[string]entry
+	if json.Unmarshal(b, &roots) != nil {
+		return c
+	}
+	// Expired entries are kept, in case they're needed while offline.
+	for root, e := range roots {
+		c.roots[root] = e
+	}
+	return c
+}
+
+// Offline makes the cache use expired repository roots too, and fail
+// on cache misses rather than resolve repository roots over the network.
+func (c *Cache) Offline() {
+	c.offline = true
+}
+
+// RepoRootForImportPath returns the repository root of Go package with import path,
+// like vcs.RepoRootForImportPath. Unexpired cached repository roots are used
+// for the import path, and for import paths under them if they're determined
+// by a static host rule. Failures aren't cached.
+// While offline, expired repository roots are used too, and misses are errors.
+func (c *Cache) RepoRootForImportPath(importPath string) (*vcs.RepoRoot, error) {
+	c.mu.Lock()
+	for root := importPath; ; {
+		// Under a dynamically resolved repository root, there may be
+		// a nested repository with its own root, so it can't be reused.
+		if e, ok := c.roots[root]; ok && (root == importPath || staticRoot(root)) && (c.offline || time.Since(e.Time) < c.ttl) {
+			if cmd := vcs.ByCmd(e.VCS); cmd != nil {
+				c.mu.Unlock()
+				return &vcs.RepoRoot{VCS: cmd, Repo: e.Repo, Root: root}, nil
+			}
+		}
+		i := strings.LastIndex(root, "/")
+		if i == -1 {
+			break
+		}
+		root = root[:i]
+	}
+	c.mu.Unlock()
+	if c.offline {
+		return nil, fmt.Errorf("repository root of %v isn't cached, and can't be resolved while offline", importPath)
+	}
+
+	// This is potentially somewhat slow.
+	rr, err := c.resolve(importPath)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.roots[rr.Root] = entry{VCS: rr.VCS.Cmd, Repo: rr.Repo, Time: time.Now().UTC()}
+	if !c.saving {
+		c.saving = true
+		time.AfterFunc(saveDelay, func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			c.saving = false
+			// The cache is only an optimization, so failing to save it isn't an error.
+			c.save()
+		})
+	}
+	return rr, nil
+}
+
+// staticHosts are prefixes of import paths whose repository roots are determined
+// by static host rules of vcs.RepoRootForImportPath, rather than over the network.
+var staticHosts = []string{
+	"github.com/",
+	"bitbucket.org/",
+	"hub.jazz.net/git/",
+	"git.apache.org/",
+	"git.openstack.org/",
+	"chiselapp.com/user/",
+}
+
+// vcsQualifier matches repository roots that end with a version control system
+// qualifier, e.g., "example.org/repo.git", which are determined statically too.
+var vcsQualifier = regexp.MustCompile(`\.(bzr|fossil|git|hg|svn)$`)
+
+// staticRoot reports whether repository root is determined by a static host rule,
+// so that import paths under it are known to belong to the same repository.
+func staticRoot(root string) bool {
+	for _, h := range staticHosts {
+		if strings.HasPrefix(root, h) {
+			return true
+		}
+	}
+	return vcsQualifier.MatchString(root)
+}
+
+// save writes unexpired entries of the cache to the file, replacing it atomically.
+// c.mu must be held.
+func (c *Cache) save() error {
+	roots := make(map[string]entry)
+	for root, e := range c.roots {
+		if time.Since(e.Time) < c.ttl {
+			roots[root] = e
+		}
+	}
+	b, err := json.MarshalIndent(roots, "", "\t")
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(c.path), 0700)
+	if err != nil {
+		return err
+	}
+	tmp := c.path + ".tmp"
+	err = ioutil.WriteFile(tmp, append(b, '\n'), 0600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
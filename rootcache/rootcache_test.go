@@ -0,0 +1,89 @@
+package rootcache
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/tools/go/vcs"
+)
+
+func TestCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reporoots.json")
+	var resolved []string
+	resolve := func(importPath string) (*vcs.RepoRoot, error) {
+		resolved = append(resolved, importPath)
+		switch {
+		case importPath == "example.com/broken":
+			return nil, fmt.Errorf("unrecognized import path %q", importPath)
+		case strings.HasPrefix(importPath, "github.com/owner/repo"):
+			return &vcs.RepoRoot{VCS: vcs.ByCmd("git"), Repo: "https://github.com/owner/repo", Root: "github.com/owner/repo"}, nil
+		default:
+			return &vcs.RepoRoot{VCS: vcs.ByCmd("git"), Repo: "https://" + importPath, Root: importPath}, nil
+		}
+	}
+
+	c := New(path, time.Hour)
+	c.resolve = resolve
+	for _, importPath := range []string{
+		"github.com/owner/repo/pkg",
+		"github.com/owner/repo",     // Cached.
+		"github.com/owner/repo/a/b", // Cached, because the root is static.
+		"example.com/vanity",
+		"example.com/vanity",        // Cached.
+		"example.com/vanity/nested", // Not cached, because it may be a nested repository.
+		"example.com/broken",
+		"example.com/broken", // Failures aren't cached.
+	} {
+		c.RepoRootForImportPath(importPath)
+	}
+	if want := []string{"github.com/owner/repo/pkg", "example.com/vanity", "example.com/vanity/nested", "example.com/broken", "example.com/broken"}; fmt.Sprint(resolved) != fmt.Sprint(want) {
+		t.Errorf("got resolved %q, want %q", resolved, want)
+	}
+	c.mu.Lock()
+	err := c.save() // Don't wait for the scheduled save.
+	c.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Cached repository roots persist across runs.
+	resolved = nil
+	c = Open(path, time.Hour)
+	c.resolve = resolve
+	rr, err := c.RepoRootForImportPath("github.com/owner/repo/pkg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rr.VCS.Cmd != "git" || rr.Repo != "https://github.com/owner/repo" || rr.Root != "github.com/owner/repo" {
+		t.Errorf("got %+v, want cached repo root", rr)
+	}
+	if len(resolved) != 0 {
+		t.Errorf("got resolved %q, want none", resolved)
+	}
+
+	// Expired repository roots are resolved again.
+	c = Open(path, 0)
+	c.resolve = resolve
+	c.RepoRootForImportPath("github.com/owner/repo/pkg")
+	if len(resolved) != 1 {
+		t.Errorf("got resolved %q, want 1", resolved)
+	}
+
+	// While offline, expired repository roots are used, and misses aren't resolved.
+	resolved = nil
+	c = Open(path, 0)
+	c.resolve = resolve
+	c.Offline()
+	if _, err := c.RepoRootForImportPath("github.com/owner/repo/pkg"); err != nil {
+		t.Errorf("got error %v, want expired repo root", err)
+	}
+	if _, err := c.RepoRootForImportPath("example.com/unknown"); err == nil {
+		t.Error("got no error for uncached repo root while offline")
+	}
+	if len(resolved) != 0 {
+		t.Errorf("got resolved %q, want none", resolved)
+	}
+}
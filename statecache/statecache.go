@@ -0,0 +1,102 @@
+// Package statecache provides a persistent store of the last-known state
+// of repositories, as fetched from their remotes, and presentations of their updates.
+//
+// It allows presenting updates when remotes can't be reached, e.g., while offline.
+package statecache
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/shurcooL/Go-Package-Store/workspace"
+)
+
+// Path returns the path of the cache file in the user cache directory.
+func Path() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "github.com", "shurcooL", "Go-Package-Store", "states.json"), nil
+}
+
+// saveDelay is how long remembered states are batched up before writing the file.
+const saveDelay = time.Second
+
+// Cache is a workspace.StateStore stored in a file.
+// It's safe for concurrent use.
+type Cache struct {
+	path string
+
+	mu     sync.Mutex
+	states map[string]workspace.KnownState // Map key is repo root.
+	saving bool                            // A save is scheduled.
+}
+
+// Open opens the cache stored in a file at path.
+// A missing or corrupted file is an empty cache.
+func Open(path string) *Cache {
+	c := &Cache{path: path, states: make(map[string]workspace.KnownState)}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return c
+	}
+	var states map[string]workspace.KnownState
+	if json.Unmarshal(b, &states) != nil {
+		return c
+	}
+	for root, s := range states {
+		c.states[root] = s
+	}
+	return c
+}
+
+// LastKnown returns the last-known state of repository with root, if any.
+func (c *Cache) LastKnown(root string) (workspace.KnownState, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.states[root]
+	return s, ok
+}
+
+// Remember remembers s as the last-known state of repository with root.
+// The file is written shortly after, together with other states remembered meanwhile.
+func (c *Cache) Remember(root string, s workspace.KnownState) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.states[root] = s
+	if c.saving {
+		return
+	}
+	c.saving = true
+	time.AfterFunc(saveDelay, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.saving = false
+		// The cache is only a fallback, so failing to save it isn't an error.
+		c.save()
+	})
+}
+
+// save writes the cache to the file, replacing it atomically.
+// c.mu must be held.
+func (c *Cache) save() error {
+	b, err := json.MarshalIndent(c.states, "", "\t")
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(c.path), 0700)
+	if err != nil {
+		return err
+	}
+	tmp := c.path + ".tmp"
+	err = ioutil.WriteFile(tmp, append(b, '\n'), 0600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
@@ -1,7 +1,65 @@
 package gps
 
+import "io"
+
 // Updater is able to update Go packages contained in repositories.
 type Updater interface {
 	// Update specified repository to latest version.
 	Update(repo *Repo) error
 }
+
+// OutputUpdater is an Updater that can write the output of updating
+// to a specified writer, rather than standard output.
+type OutputUpdater interface {
+	Updater
+
+	// UpdateOutput updates specified repository to latest version,
+	// writing the output of updating to w.
+	UpdateOutput(repo *Repo, w io.Writer) error
+}
+
+// Reverter is able to revert repositories to a previous revision,
+// such as the revision before an update.
+type Reverter interface {
+	// Revert specified repository to revision,
+	// writing the output of reverting to w.
+	Revert(repo *Repo, revision string, w io.Writer) error
+}
+
+// Planner is able to describe what updating a repository would do,
+// without updating it.
+type Planner interface {
+	// Plan returns what updating specified repository to latest version would do.
+	Plan(repo *Repo) (Plan, error)
+}
+
+// Plan describes what updating a repository would do.
+type Plan struct {
+	// Commands that would be executed, in order,
+	// e.g., "cd /path/to/repo", "git pull --ff-only".
+	Commands []string
+
+	// Edits describe files that would be modified by means
+	// other than executing Commands, if any.
+	Edits []string
+}
+
+// Rebaser is able to update repositories whose local branch has diverged
+// from the remote branch, by rebasing local commits onto the remote branch.
+type Rebaser interface {
+	// Rebase rebases local commits of specified repository onto
+	// the remote revision, writing the output of rebasing to w.
+	// If rebasing fails, it's aborted, leaving the repository unchanged.
+	Rebase(repo *Repo, w io.Writer) error
+}
+
+// SharedStateUpdater is an Updater whose updates of different repositories
+// modify shared state, such as a manifest file.
+type SharedStateUpdater interface {
+	Updater
+
+	// SharedState returns identifiers of shared state that updating
+	// specified repository modifies. Updates with a common identifier
+	// must not be performed concurrently.
+	SharedState(repo *Repo) []string
+}
@@ -1,9 +1,14 @@
 package updater
 
 import (
+	"bytes"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"regexp"
 	"strings"
 
 	"github.com/shurcooL/Go-Package-Store"
@@ -21,11 +26,102 @@ type Dep struct {
 // Update specified repository to latest version by calling
 // "dep ensure -update <repo-root>" in d.Dir directory.
 func (d Dep) Update(repo *gps.Repo) error {
+	return d.UpdateOutput(repo, os.Stdout)
+}
+
+// UpdateOutput is like Update, but writes the output of updating to w.
+func (d Dep) UpdateOutput(repo *gps.Repo, w io.Writer) error {
 	cmd := exec.Command("dep", "ensure", "-update", repo.Root)
-	fmt.Println(strings.Join(cmd.Args, " "))
+	fmt.Fprintln(w, strings.Join(cmd.Args, " "))
 	cmd.Dir = d.Dir
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd.Stdout = w
+	cmd.Stderr = w
 	err := cmd.Run()
 	return err
 }
+
+// SharedState returns the Gopkg.lock file and vendor directory in d.Dir directory,
+// which are modified by updates of all repositories.
+func (d Dep) SharedState(repo *gps.Repo) []string {
+	dir, err := filepath.Abs(d.Dir)
+	if err != nil {
+		dir = d.Dir
+	}
+	return []string{filepath.Join(dir, "Gopkg.lock"), filepath.Join(dir, "vendor")}
+}
+
+// Plan returns what updating specified repository to latest version would do.
+func (d Dep) Plan(repo *gps.Repo) (gps.Plan, error) {
+	dir := d.Dir
+	if dir == "" {
+		dir = "."
+	}
+	return gps.Plan{
+		Commands: []string{
+			"cd " + dir,
+			"dep ensure -update " + repo.Root,
+		},
+		Edits: []string{
+			fmt.Sprintf("%v: set revision of %v to %v", filepath.Join(dir, "Gopkg.lock"), repo.Root, repo.Remote.Revision),
+			fmt.Sprintf("%v: replace with revision %v", filepath.Join(dir, "vendor", filepath.FromSlash(repo.Root)), repo.Remote.Revision),
+		},
+	}, nil
+}
+
+// Revert specified repository to revision by setting its revision in
+// Gopkg.lock file in d.Dir directory and calling "dep ensure -vendor-only".
+func (d Dep) Revert(repo *gps.Repo, revision string, w io.Writer) error {
+	lockPath := filepath.Join(d.Dir, "Gopkg.lock")
+	lock, err := ioutil.ReadFile(lockPath)
+	if err != nil {
+		return err
+	}
+	lock, err = setLockRevision(lock, repo.Root, revision)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "setting revision of %v in %v to %v\n", repo.Root, lockPath, revision)
+	err = ioutil.WriteFile(lockPath, lock, 0644)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command("dep", "ensure", "-vendor-only")
+	fmt.Fprintln(w, strings.Join(cmd.Args, " "))
+	cmd.Dir = d.Dir
+	cmd.Stdout = w
+	cmd.Stderr = w
+	err = cmd.Run()
+	return err
+}
+
+var (
+	lockTable    = regexp.MustCompile(`(?m)^\[`)
+	lockRevision = regexp.MustCompile(`(?m)^(\s*revision\s*=\s*)"[^"]*"`)
+)
+
+// setLockRevision returns Gopkg.lock file contents lock,
+// with revision of project with specified root set to revision.
+func setLockRevision(lock []byte, root, revision string) ([]byte, error) {
+	tables := lockTable.FindAllIndex(lock, -1)
+	for i, t := range tables {
+		start, end := t[0], len(lock)
+		if i+1 < len(tables) {
+			end = tables[i+1][0]
+		}
+		table := lock[start:end]
+		if !bytes.Contains(table, []byte(fmt.Sprintf("name = %q", root))) {
+			continue
+		}
+		loc := lockRevision.FindSubmatchIndex(table)
+		if loc == nil {
+			return nil, fmt.Errorf("project %v in Gopkg.lock has no revision", root)
+		}
+		var buf bytes.Buffer
+		buf.Write(lock[:start+loc[3]])
+		fmt.Fprintf(&buf, "%q", revision)
+		buf.Write(lock[start+loc[1]:])
+		return buf.Bytes(), nil
+	}
+	return nil, fmt.Errorf("project %v not found in Gopkg.lock", root)
+}
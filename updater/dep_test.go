@@ -0,0 +1,49 @@
+package updater
+
+import "testing"
+
+func TestSetLockRevision(t *testing.T) {
+	const lock = `# This file is autogenerated, do not edit; changes may be undone by the next 'dep ensure'.
+
+
+[[projects]]
+  name = "github.com/foo/bar"
+  packages = ["."]
+  revision = "1111111111111111111111111111111111111111"
+
+[[projects]]
+  name = "github.com/foo/barbaz"
+  packages = ["."]
+  revision = "2222222222222222222222222222222222222222"
+
+[solve-meta]
+  analyzer-name = "dep"
+`
+	const want = `# This file is autogenerated, do not edit; changes may be undone by the next 'dep ensure'.
+
+
+[[projects]]
+  name = "github.com/foo/bar"
+  packages = ["."]
+  revision = "1111111111111111111111111111111111111111"
+
+[[projects]]
+  name = "github.com/foo/barbaz"
+  packages = ["."]
+  revision = "3333333333333333333333333333333333333333"
+
+[solve-meta]
+  analyzer-name = "dep"
+`
+	got, err := setLockRevision([]byte(lock), "github.com/foo/barbaz", "3333333333333333333333333333333333333333")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	if _, err := setLockRevision([]byte(lock), "github.com/other/repo", "3333"); err == nil {
+		t.Error("got nil error for project that's not in Gopkg.lock")
+	}
+}
@@ -0,0 +1,48 @@
+package updater
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/shurcooL/Go-Package-Store"
+)
+
+// DryRun is an Updater that reports what Updater would do to update
+// a repository, without updating it or touching disk.
+type DryRun struct {
+	Updater gps.Updater
+}
+
+// Update reports what updating specified repository to latest version would do.
+func (d DryRun) Update(repo *gps.Repo) error {
+	return d.UpdateOutput(repo, os.Stdout)
+}
+
+// UpdateOutput is like Update, but writes the report to w.
+func (d DryRun) UpdateOutput(repo *gps.Repo, w io.Writer) error {
+	plan, err := d.Plan(repo)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "dry run: would update %v from %v to %v\n", repo.Root, repo.Local.Revision, repo.Remote.Revision)
+	for _, c := range plan.Commands {
+		fmt.Fprintln(w, "dry run: would run:", c)
+	}
+	for _, e := range plan.Edits {
+		fmt.Fprintln(w, "dry run: would edit:", e)
+	}
+	return nil
+}
+
+// Plan returns what updating specified repository to latest version would do.
+// If d.Updater isn't a gps.Planner, the plan can't be more specific than
+// the Updater that would be used.
+func (d DryRun) Plan(repo *gps.Repo) (gps.Plan, error) {
+	if p, ok := d.Updater.(gps.Planner); ok {
+		return p.Plan(repo)
+	}
+	return gps.Plan{
+		Commands: []string{fmt.Sprintf("(%T).Update(%q)", d.Updater, repo.Root)},
+	}, nil
+}
@@ -2,21 +2,274 @@ package updater
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 
 	"github.com/shurcooL/Go-Package-Store"
 )
 
 // Gopath is an Updater that updates Go packages in local GOPATH workspaces.
-type Gopath struct{}
+type Gopath struct {
+	// Stash makes updates of git repositories with dirty working trees
+	// stash local changes, update, and re-apply the stashed changes.
+	// Otherwise, updating a repository with a dirty working tree may fail.
+	Stash bool
+}
 
 // Update specified repository to latest version.
-func (Gopath) Update(repo *gps.Repo) error {
+func (g Gopath) Update(repo *gps.Repo) error {
+	return g.UpdateOutput(repo, os.Stdout)
+}
+
+// UpdateOutput updates specified repository to latest version,
+// writing the output of updating to w.
+func (g Gopath) UpdateOutput(repo *gps.Repo, w io.Writer) error {
 	if repo.VCS == nil || repo.Path == "" || repo.Cmd == nil {
 		return fmt.Errorf("missing information needed to update Go package in GOPATH: %#v", repo)
 	}
 
-	fmt.Printf("cd %s\n", repo.Path)
-	fmt.Printf("%s %s", repo.Cmd.Cmd, repo.Cmd.DownloadCmd)
-	err := repo.Cmd.Download(repo.Path)
+	stash, err := g.shouldStash(repo)
+	if err != nil {
+		return err
+	}
+	if stash {
+		return updateStashed(repo, w)
+	}
+
+	cmd := exec.Command(repo.Cmd.Cmd, strings.Fields(repo.Cmd.DownloadCmd)...)
+	fmt.Fprintf(w, "cd %s\n", repo.Path)
+	fmt.Fprintln(w, strings.Join(cmd.Args, " "))
+	cmd.Dir = repo.Path
+	cmd.Stdout = w
+	cmd.Stderr = w
+	err = cmd.Run()
+	return err
+}
+
+// Plan returns what updating specified repository to latest version would do.
+func (g Gopath) Plan(repo *gps.Repo) (gps.Plan, error) {
+	if repo.VCS == nil || repo.Path == "" || repo.Cmd == nil {
+		return gps.Plan{}, fmt.Errorf("missing information needed to update Go package in GOPATH: %#v", repo)
+	}
+	if repo.Local.Diverged {
+		if repo.Cmd.Cmd != "git" {
+			return gps.Plan{}, fmt.Errorf("rebasing %v repositories is not supported", repo.Cmd.Name)
+		}
+		return gps.Plan{
+			Commands: []string{
+				"cd " + repo.Path,
+				"git fetch origin " + repo.Remote.Branch,
+				strings.Join(g.rebaseArgs(repo), " "),
+			},
+		}, nil
+	}
+	stash, err := g.shouldStash(repo)
+	if err != nil {
+		return gps.Plan{}, err
+	}
+	if stash {
+		return gps.Plan{
+			Commands: []string{
+				"cd " + repo.Path,
+				strings.Join(stashPushArgs, " "),
+				repo.Cmd.Cmd + " " + repo.Cmd.DownloadCmd,
+				"git stash pop",
+			},
+		}, nil
+	}
+	return gps.Plan{
+		Commands: []string{
+			"cd " + repo.Path,
+			repo.Cmd.Cmd + " " + repo.Cmd.DownloadCmd,
+		},
+	}, nil
+}
+
+// shouldStash reports whether local changes in repo need to be stashed while updating it.
+func (g Gopath) shouldStash(repo *gps.Repo) (bool, error) {
+	if !g.Stash {
+		return false, nil
+	}
+	status, err := repo.VCS.Status(repo.Path)
+	if err != nil {
+		return false, fmt.Errorf("error determining if working tree is dirty: %v", err)
+	}
+	if status == "" {
+		return false, nil
+	}
+	if repo.Cmd.Cmd != "git" {
+		return false, fmt.Errorf("working tree is dirty, and stashing local changes of %v repositories is not supported", repo.Cmd.Name)
+	}
+	return true, nil
+}
+
+// stashPushArgs is the command that stashes local changes, including untracked files,
+// so that a dirty working tree always results in a stash entry.
+var stashPushArgs = []string{"git", "stash", "push", "--include-untracked", "--message", "Go Package Store: local changes before update"}
+
+// StashError is the error that updating a repository with a dirty working tree fails with
+// when the update itself succeeded, but re-applying the stashed local changes didn't.
+// The repository is updated, and the local changes are kept in the stash.
+type StashError struct {
+	Conflicts []string // Files with unresolved conflicts, if re-applying conflicted.
+	Err       error    // Error that re-applying the stashed local changes failed with.
+}
+
+func (e StashError) Error() string {
+	if len(e.Conflicts) == 0 {
+		return fmt.Sprintf("updated, but re-applying stashed local changes failed: %v; they're kept in the stash, re-apply them with \"git stash pop\"", e.Err)
+	}
+	return fmt.Sprintf("updated, but re-applying stashed local changes conflicted in:\n%s\n"+
+		"resolve the conflicts, then drop the stash with \"git stash drop\"", strings.Join(e.Conflicts, "\n"))
+}
+
+// updateStashed updates git repository repo with a dirty working tree
+// by stashing local changes, updating, and re-applying the stashed changes,
+// writing the output to w. If only re-applying fails, the changes are kept
+// in the stash, and a StashError listing the conflicting files is returned.
+func updateStashed(repo *gps.Repo, w io.Writer) error {
+	fmt.Fprintf(w, "cd %s\n", repo.Path)
+	stashBefore := stashRevision(repo.Path)
+	err := run(w, repo.Path, stashPushArgs...)
+	if err != nil {
+		return fmt.Errorf("stashing local changes failed: %v", err)
+	}
+	if stashRevision(repo.Path) == stashBefore {
+		return fmt.Errorf("stashing local changes failed: nothing was stashed")
+	}
+
+	updateErr := run(w, repo.Path, append([]string{repo.Cmd.Cmd}, strings.Fields(repo.Cmd.DownloadCmd)...)...)
+
+	err = run(w, repo.Path, "git", "stash", "pop")
+	switch {
+	case err != nil && updateErr != nil:
+		return fmt.Errorf("%v; re-applying stashed local changes failed: %v, they're kept in the stash", updateErr, err)
+	case err != nil:
+		return StashError{Conflicts: strings.Fields(conflictedFiles(repo.Path)), Err: err}
+	}
+	return updateErr
+}
+
+// Rebase rebases local commits of specified git repository onto the remote revision,
+// writing the output of rebasing to w. The remote revision is fetched first.
+// If rebasing conflicts, it's aborted, and the returned error lists the conflicting files.
+func (g Gopath) Rebase(repo *gps.Repo, w io.Writer) error {
+	if repo.VCS == nil || repo.Path == "" || repo.Cmd == nil {
+		return fmt.Errorf("missing information needed to rebase Go package in GOPATH: %#v", repo)
+	}
+	if repo.Cmd.Cmd != "git" {
+		return fmt.Errorf("rebasing %v repositories is not supported", repo.Cmd.Name)
+	}
+
+	fmt.Fprintf(w, "cd %s\n", repo.Path)
+	err := run(w, repo.Path, "git", "fetch", "origin", repo.Remote.Branch)
+	if err != nil {
+		return fmt.Errorf("fetching remote branch failed: %v", err)
+	}
+	err = run(w, repo.Path, g.rebaseArgs(repo)...)
+	if err == nil {
+		return nil
+	}
+	if !rebaseInProgress(repo.Path) {
+		// Rebasing failed before it started, e.g., because of a dirty working tree.
+		return fmt.Errorf("rebasing failed: %v", err)
+	}
+	conflicts := conflictedFiles(repo.Path)
+	if abortErr := run(w, repo.Path, "git", "rebase", "--abort"); abortErr != nil {
+		return fmt.Errorf("rebasing failed: %v; aborting the rebase failed too: %v, finish or abort it manually", err, abortErr)
+	}
+	if conflicts == "" {
+		return fmt.Errorf("rebasing failed: %v; the rebase was aborted, leaving the repository unchanged", err)
+	}
+	return fmt.Errorf("rebasing local commits conflicted in:\n%s"+
+		"the rebase was aborted, leaving the repository unchanged", conflicts)
+}
+
+// rebaseArgs returns the command that rebases local commits of repo onto the remote revision.
+func (g Gopath) rebaseArgs(repo *gps.Repo) []string {
+	args := []string{"git", "rebase"}
+	if g.Stash {
+		args = append(args, "--autostash")
+	}
+	return append(args, repo.Remote.Revision)
+}
+
+// rebaseInProgress reports whether git repository at dir has a rebase in progress.
+func rebaseInProgress(dir string) bool {
+	for _, name := range []string{"rebase-merge", "rebase-apply"} {
+		cmd := exec.Command("git", "rev-parse", "--git-path", name)
+		cmd.Dir = dir
+		out, err := cmd.Output()
+		if err != nil {
+			continue
+		}
+		path := strings.TrimSpace(string(out))
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		if _, err := os.Stat(path); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// conflictedFiles returns the newline separated list of files with unresolved conflicts
+// in git repository at dir, or empty string if there aren't any.
+func conflictedFiles(dir string) string {
+	cmd := exec.Command("git", "diff", "--name-only", "--diff-filter=U")
+	cmd.Dir = dir
+	out, _ := cmd.Output()
+	return string(out)
+}
+
+// stashRevision returns the revision of the latest stash entry in git repository at dir,
+// or empty string if there isn't one.
+func stashRevision(dir string) string {
+	cmd := exec.Command("git", "rev-parse", "--quiet", "--verify", "refs/stash")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// run runs command args in dir, writing the command and its output to w.
+func run(w io.Writer, dir string, args ...string) error {
+	cmd := exec.Command(args[0], args[1:]...)
+	fmt.Fprintln(w, strings.Join(cmd.Args, " "))
+	cmd.Dir = dir
+	cmd.Stdout = w
+	cmd.Stderr = w
+	return cmd.Run()
+}
+
+// Revert specified repository to revision, writing the output of reverting to w.
+// Only git and hg repositories are supported. Git repositories stay on their branch.
+func (Gopath) Revert(repo *gps.Repo, revision string, w io.Writer) error {
+	if repo.VCS == nil || repo.Path == "" || repo.Cmd == nil {
+		return fmt.Errorf("missing information needed to revert Go package in GOPATH: %#v", repo)
+	}
+
+	var cmd *exec.Cmd
+	switch repo.Cmd.Cmd {
+	case "git":
+		// Move the current branch back, keeping any uncommitted changes.
+		cmd = exec.Command("git", "reset", "--keep", revision)
+	case "hg":
+		cmd = exec.Command("hg", "update", "--check", "--rev", revision)
+	default:
+		return fmt.Errorf("reverting %v repositories is not supported", repo.Cmd.Name)
+	}
+	fmt.Fprintf(w, "cd %s\n", repo.Path)
+	fmt.Fprintln(w, strings.Join(cmd.Args, " "))
+	cmd.Dir = repo.Path
+	cmd.Stdout = w
+	cmd.Stderr = w
+	err := cmd.Run()
 	return err
 }
@@ -0,0 +1,171 @@
+package updater
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/shurcooL/Go-Package-Store"
+	"github.com/shurcooL/vcsstate"
+	"golang.org/x/tools/go/vcs"
+)
+
+func TestUpdateStashed(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git binary is not available:", err)
+	}
+
+	tests := []struct {
+		name         string
+		local        string // Local change to file.txt.
+		wantConflict bool
+	}{
+		{name: "reapplied", local: "one\ntwo\nthree, changed locally\n"},
+		{name: "conflict", local: "one, changed locally\ntwo\nthree\n", wantConflict: true},
+	}
+	// Stashing creates commits.
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// Set up a remote repository, and a local clone that's behind it.
+			remote, local := t.TempDir(), t.TempDir()
+			git(t, remote, "init", "--quiet")
+			writeFile(t, remote, "file.txt", "one\ntwo\nthree\n")
+			git(t, remote, "add", ".")
+			git(t, remote, "commit", "--quiet", "--message", "first")
+			git(t, local, "clone", "--quiet", remote, ".")
+			writeFile(t, remote, "file.txt", "one, changed remotely\ntwo\nthree\n")
+			git(t, remote, "commit", "--quiet", "--all", "--message", "second")
+			want := strings.TrimSpace(git(t, remote, "rev-parse", "HEAD"))
+
+			// Make the working tree dirty.
+			writeFile(t, local, "file.txt", tc.local)
+			writeFile(t, local, "untracked.txt", "untracked\n")
+
+			repo := &gps.Repo{Root: "example.com/repo", Path: local, Cmd: vcs.ByCmd("git")}
+			err := updateStashed(repo, io.Discard)
+			if got := strings.TrimSpace(git(t, local, "rev-parse", "HEAD")); got != want {
+				t.Errorf("got revision %q, want %q", got, want)
+			}
+			if tc.wantConflict {
+				if se, ok := err.(StashError); !ok || !reflect.DeepEqual(se.Conflicts, []string{"file.txt"}) {
+					t.Errorf("got error %v, want stash conflict in file.txt", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := readFile(t, local, "file.txt"); got != "one, changed remotely\ntwo\nthree, changed locally\n" {
+				t.Errorf("got file.txt %q, want both remote and local changes", got)
+			}
+			if got := readFile(t, local, "untracked.txt"); got != "untracked\n" {
+				t.Errorf("got untracked.txt %q, want it restored", got)
+			}
+			if got := git(t, local, "stash", "list"); got != "" {
+				t.Errorf("got stash list %q, want it empty", got)
+			}
+		})
+	}
+}
+
+func TestRebase(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git binary is not available:", err)
+	}
+
+	tests := []struct {
+		name         string
+		local        string // Local commit to file.txt.
+		wantConflict bool
+	}{
+		{name: "rebased", local: "one\ntwo\nthree, changed locally\n"},
+		{name: "conflict", local: "one, changed locally\ntwo\nthree\n", wantConflict: true},
+	}
+	// Rebasing creates commits.
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// Set up a remote repository, and a local clone that diverged from it.
+			remote, local := t.TempDir(), t.TempDir()
+			git(t, remote, "init", "--quiet")
+			writeFile(t, remote, "file.txt", "one\ntwo\nthree\n")
+			git(t, remote, "add", ".")
+			git(t, remote, "commit", "--quiet", "--message", "first")
+			git(t, local, "clone", "--quiet", remote, ".")
+			writeFile(t, remote, "file.txt", "one, changed remotely\ntwo\nthree\n")
+			git(t, remote, "commit", "--quiet", "--all", "--message", "second")
+			writeFile(t, local, "file.txt", tc.local)
+			git(t, local, "commit", "--quiet", "--all", "--message", "local")
+			before := git(t, local, "rev-parse", "HEAD")
+
+			repo := &gps.Repo{Root: "example.com/repo", Path: local, Cmd: vcs.ByCmd("git")}
+			repo.VCS = fakeVCS{}
+			repo.Remote.Branch = strings.TrimSpace(git(t, remote, "rev-parse", "--abbrev-ref", "HEAD"))
+			repo.Remote.Revision = strings.TrimSpace(git(t, remote, "rev-parse", "HEAD"))
+			err := Gopath{}.Rebase(repo, io.Discard)
+			if tc.wantConflict {
+				if err == nil || !strings.Contains(err.Error(), "conflicted in:\nfile.txt\n") {
+					t.Errorf("got error %v, want conflict in file.txt", err)
+				}
+				if got := git(t, local, "rev-parse", "HEAD"); got != before {
+					t.Errorf("got revision %q, want it unchanged %q", got, before)
+				}
+				if got := git(t, local, "status", "--porcelain"); got != "" {
+					t.Errorf("got status %q, want clean working tree", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.TrimSpace(git(t, local, "rev-parse", "HEAD~1")); got != repo.Remote.Revision {
+				t.Errorf("got parent revision %q, want remote revision %q", got, repo.Remote.Revision)
+			}
+			if got := readFile(t, local, "file.txt"); got != "one, changed remotely\ntwo\nthree, changed locally\n" {
+				t.Errorf("got file.txt %q, want both remote and local changes", got)
+			}
+		})
+	}
+}
+
+// fakeVCS is a vcsstate.VCS that's only used to satisfy non-nil checks.
+type fakeVCS struct{ vcsstate.VCS }
+
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return string(out)
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, dir, name string) string {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
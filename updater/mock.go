@@ -2,6 +2,8 @@ package updater
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"time"
 
 	"github.com/shurcooL/Go-Package-Store"
@@ -11,10 +13,40 @@ import (
 type Mock struct{}
 
 // Update pretends to update specified repository to latest version.
-func (Mock) Update(repo *gps.Repo) error {
-	fmt.Println("Mock: got update request:", repo.Root)
+func (m Mock) Update(repo *gps.Repo) error {
+	return m.UpdateOutput(repo, os.Stdout)
+}
+
+// UpdateOutput is like Update, but writes the output of updating to w.
+func (Mock) UpdateOutput(repo *gps.Repo, w io.Writer) error {
+	fmt.Fprintln(w, "Mock: got update request:", repo.Root)
+	const mockDelay = 3 * time.Second
+	fmt.Fprintf(w, "pretending to update (actually sleeping for %v)", mockDelay)
+	time.Sleep(mockDelay)
+	return nil
+}
+
+// Plan returns what pretending to update specified repository would do.
+func (Mock) Plan(repo *gps.Repo) (gps.Plan, error) {
+	return gps.Plan{
+		Commands: []string{"sleep 3"},
+	}, nil
+}
+
+// Rebase pretends to rebase local commits of specified repository onto the remote revision.
+func (Mock) Rebase(repo *gps.Repo, w io.Writer) error {
+	fmt.Fprintln(w, "Mock: got rebase request:", repo.Root)
+	const mockDelay = 3 * time.Second
+	fmt.Fprintf(w, "pretending to rebase (actually sleeping for %v)", mockDelay)
+	time.Sleep(mockDelay)
+	return nil
+}
+
+// Revert pretends to revert specified repository to revision.
+func (Mock) Revert(repo *gps.Repo, revision string, w io.Writer) error {
+	fmt.Fprintln(w, "Mock: got revert request:", repo.Root, revision)
 	const mockDelay = 3 * time.Second
-	fmt.Printf("pretending to update (actually sleeping for %v)", mockDelay)
+	fmt.Fprintf(w, "pretending to revert (actually sleeping for %v)", mockDelay)
 	time.Sleep(mockDelay)
 	return nil
 }
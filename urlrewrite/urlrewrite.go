@@ -0,0 +1,102 @@
+// Package urlrewrite rewrites remote repository URLs to their canonical form,
+// so that different URLs of the same repository can be compared.
+package urlrewrite
+
+import (
+	"bufio"
+	"os/exec"
+	"strings"
+)
+
+// Rule rewrites URLs that start with Prefix to start with Replacement instead.
+type Rule struct {
+	Prefix      string
+	Replacement string
+}
+
+// Rules are URL rewrite rules.
+type Rules []Rule
+
+// Rewrite rewrites url using the rule with the longest matching prefix, like git does.
+// Then, SSH URLs of the form "git@host:path" or "ssh://git@host/path" are converted
+// to HTTPS URLs of the form "https://host/path", with ".git" suffix removed.
+// If there are no rules, url is returned unmodified.
+func (rs Rules) Rewrite(url string) string {
+	if len(rs) == 0 {
+		return url
+	}
+	var match *Rule
+	for i, r := range rs {
+		if strings.HasPrefix(url, r.Prefix) && (match == nil || len(r.Prefix) > len(match.Prefix)) {
+			match = &rs[i]
+		}
+	}
+	if match != nil {
+		url = match.Replacement + url[len(match.Prefix):]
+	}
+	return sshToHTTPS(url)
+}
+
+// sshToHTTPS converts SSH URL url to an HTTPS URL.
+// Other URLs are returned unmodified.
+func sshToHTTPS(url string) string {
+	var host, path string
+	switch i, j := strings.Index(url, ":"), strings.Index(url, "/"); {
+	case strings.HasPrefix(url, "ssh://"):
+		hostPath := url[len("ssh://"):]
+		slash := strings.Index(hostPath, "/")
+		if slash == -1 {
+			return url
+		}
+		host, path = hostPath[:slash], hostPath[slash+1:]
+		if k := strings.LastIndex(host, ":"); k != -1 {
+			host = host[:k] // Remove port.
+		}
+	case i > 0 && (j == -1 || i < j) && !strings.Contains(url, "://"):
+		// An scp-like URL, e.g., "git@github.com:owner/repo.git".
+		host, path = url[:i], url[i+1:]
+	default:
+		return url
+	}
+	if k := strings.LastIndex(host, "@"); k != -1 {
+		host = host[k+1:] // Remove user.
+	}
+	return "https://" + host + "/" + strings.TrimSuffix(strings.TrimPrefix(path, "/"), ".git")
+}
+
+// GitInsteadOf returns rules that undo the url.<base>.insteadOf rewrites
+// in the user's git configuration. Each rule rewrites URLs that start with base
+// back to the URL prefix that git replaces with base.
+func GitInsteadOf() (Rules, error) {
+	out, err := exec.Command("git", "config", "--get-regexp", `^url\..*\.insteadof$`).Output()
+	if e, ok := err.(*exec.ExitError); ok && e.ExitCode() == 1 {
+		// No matching config values.
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return parseInsteadOf(string(out)), nil
+}
+
+// parseInsteadOf parses the output of "git config --get-regexp" for insteadOf keys.
+// If a base has multiple insteadOf values, the first one is used.
+func parseInsteadOf(out string) Rules {
+	var (
+		rs   Rules
+		seen = make(map[string]bool)
+	)
+	sc := bufio.NewScanner(strings.NewReader(out))
+	for sc.Scan() {
+		key, value, ok := strings.Cut(sc.Text(), " ")
+		if !ok || !strings.HasPrefix(key, "url.") || !strings.HasSuffix(strings.ToLower(key), ".insteadof") {
+			continue
+		}
+		base := key[len("url.") : len(key)-len(".insteadof")]
+		if seen[base] {
+			continue
+		}
+		seen[base] = true
+		rs = append(rs, Rule{Prefix: base, Replacement: value})
+	}
+	return rs
+}
@@ -0,0 +1,52 @@
+package urlrewrite
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRewrite(t *testing.T) {
+	rs := Rules{
+		{Prefix: "https://mirror.example.com/github/", Replacement: "https://github.com/"},
+		{Prefix: "https://mirror.example.com/github/special/", Replacement: "https://github.com/other/"},
+		{Prefix: "gh:", Replacement: "https://github.com/"},
+	}
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"https://github.com/owner/repo", "https://github.com/owner/repo"},
+		{"https://mirror.example.com/github/owner/repo", "https://github.com/owner/repo"},
+		{"https://mirror.example.com/github/special/repo", "https://github.com/other/repo"}, // Longest prefix wins.
+		{"gh:owner/repo", "https://github.com/owner/repo"},
+		{"git@github.com:owner/repo.git", "https://github.com/owner/repo"},
+		{"ssh://git@github.com/owner/repo.git", "https://github.com/owner/repo"},
+		{"ssh://git@example.com:2222/owner/repo", "https://example.com/owner/repo"},
+		{"/local/path/repo", "/local/path/repo"},
+	}
+	for _, tc := range tests {
+		if got := rs.Rewrite(tc.in); got != tc.want {
+			t.Errorf("Rewrite(%q): got %q, want %q", tc.in, got, tc.want)
+		}
+	}
+
+	// Without rules, URLs are left alone.
+	if got, want := Rules(nil).Rewrite("git@github.com:owner/repo.git"), "git@github.com:owner/repo.git"; got != want {
+		t.Errorf("Rewrite without rules: got %q, want %q", got, want)
+	}
+}
+
+func TestParseInsteadOf(t *testing.T) {
+	const out = `url.git@github.com:.insteadof https://github.com/
+url.git@github.com:.insteadof gh:
+url.https://mirror.example.com/go/.insteadOf https://go.googlesource.com/
+`
+	got := parseInsteadOf(out)
+	want := Rules{
+		{Prefix: "git@github.com:", Replacement: "https://github.com/"},
+		{Prefix: "https://mirror.example.com/go/", Replacement: "https://go.googlesource.com/"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,110 @@
+package workspace
+
+import (
+	"go/build"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// addPackage records Go package with specified import path in directory dir,
+// along with its imports, for the reverse-dependency index.
+// Directories without a Go package are skipped.
+func (p *Pipeline) addPackage(importPath, dir string) {
+	bpkg, err := build.ImportDir(dir, build.IgnoreVendor)
+	if err != nil {
+		return
+	}
+	p.reposMu.Lock()
+	p.packages[importPath] = bpkg.Imports
+	p.reposMu.Unlock()
+}
+
+// addPackages records all Go packages inside local repository
+// with specified root at dir, for the reverse-dependency index.
+func (p *Pipeline) addPackages(root, dir string) {
+	// TODO: Confirm that ignoring filepath.Walk error is correct/desired behavior.
+	_ = filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil || !fi.IsDir() {
+			return nil
+		}
+		if path != dir && (strings.HasPrefix(fi.Name(), ".") || strings.HasPrefix(fi.Name(), "_") ||
+			fi.Name() == "testdata" || fi.Name() == "vendor") {
+			return filepath.SkipDir
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return nil
+		}
+		importPath := root
+		if rel != "." {
+			importPath += "/" + filepath.ToSlash(rel)
+		}
+		p.addPackage(importPath, path)
+		return nil
+	})
+}
+
+// indexImporters builds the reverse-dependency index from the Go packages
+// found in the workspace, and closes p.indexed. It must be called after
+// all repositories and packages have been found.
+func (p *Pipeline) indexImporters() {
+	p.reposMu.Lock()
+	importers := make(map[string]map[string]struct{}) // Repo root -> set of importing packages.
+	for importPath, imports := range p.packages {
+		from := p.repoRoot(importPath)
+		for _, imp := range imports {
+			root := p.repoRoot(imp)
+			if root == "" || root == from {
+				// Not a repository in the workspace, or an import within the same repository.
+				continue
+			}
+			if importers[root] == nil {
+				importers[root] = make(map[string]struct{})
+			}
+			importers[root][importPath] = struct{}{}
+		}
+	}
+	p.reposMu.Unlock()
+
+	p.importedBy = make(map[string][]string)
+	for root, set := range importers {
+		var importPaths []string
+		for importPath := range set {
+			importPaths = append(importPaths, importPath)
+		}
+		sort.Strings(importPaths)
+		p.importedBy[root] = importPaths
+	}
+	close(p.indexed)
+}
+
+// RepoPackages returns import paths of Go packages found so far in the workspace
+// inside the repository with specified root, sorted. Packages of repositories
+// nested inside it are excluded. It's empty for repositories that aren't local.
+func (p *Pipeline) RepoPackages(root string) []string {
+	p.reposMu.Lock()
+	defer p.reposMu.Unlock()
+	var importPaths []string
+	for importPath := range p.packages {
+		if p.repoRoot(importPath) == root {
+			importPaths = append(importPaths, importPath)
+		}
+	}
+	sort.Strings(importPaths)
+	return importPaths
+}
+
+// repoRoot returns the root of repository that contains Go package
+// with specified import path, or empty string if it's not in the workspace.
+// p.reposMu must be held.
+func (p *Pipeline) repoRoot(importPath string) string {
+	for ip := importPath; ip != "." && ip != "/"; ip = path.Dir(ip) {
+		if _, ok := p.repos[ip]; ok {
+			return ip
+		}
+	}
+	return ""
+}
@@ -3,12 +3,18 @@ package workspace
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"go/build"
 	"log"
+	"os/exec"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/bradfitz/iter"
+	"github.com/dustin/go-humanize"
 	"github.com/shurcooL/Go-Package-Store"
 	"github.com/shurcooL/Go-Package-Store/presenter"
 	"github.com/shurcooL/gostatus/status"
@@ -22,6 +28,28 @@ type Pipeline struct {
 
 	// presenters are presenters registered with RegisterPresenter.
 	presenters []presenter.Presenter
+	// batchPresenters are batch presenters registered with RegisterBatchPresenter.
+	batchPresenters []presenter.BatchPresenter
+	// enrichers are enrichers registered with RegisterEnricher.
+	enrichers []presenter.Enricher
+	// filters are filters registered with RegisterFilter.
+	filters []Filter
+	// urlRewriters are URL rewriters registered with RegisterURLRewriter.
+	urlRewriters []URLRewriter
+	// repoRootResolver is the repository root resolver set with ResolveRepoRootsWith.
+	repoRootResolver RepoRootResolver
+	// forkComparer is the fork comparer set with CompareForksWith.
+	forkComparer ForkComparer
+	// fetchForks is whether to fetch upstream commits of forks that can't be compared otherwise.
+	fetchForks bool
+	// presentOpts are options for presenting updates that are otherwise skipped.
+	presentOpts presentOptions
+	// trackUpstream is whether to track upstream branches of checked out local branches.
+	trackUpstream bool
+	// stateStore is where the last-known state of repos is remembered, set with RememberStateIn.
+	stateStore StateStore
+	// offline is whether to use the last-known remote state of repos, rather than fetch it.
+	offline bool
 
 	importPaths         chan string
 	importPathRevisions chan importPathRevision
@@ -33,12 +61,24 @@ type Pipeline struct {
 	unique chan *gps.Repo
 	// processedFiltered is the output of processed repos (complete with local and remote revisions),
 	// with just enough information to decide if an update should be displayed.
-	processedFiltered chan *gps.Repo
+	processedFiltered chan *RepoPresentation
 	// presented is the output of processed and presented repos (complete with presenter.Presentation).
 	presented chan *RepoPresentation
 
 	reposMu sync.Mutex
 	repos   map[string]*gps.Repo // Map key is the import path corresponding to the root of the repository.
+	// packages are Go packages found in the workspace, also protected by reposMu.
+	// Map key is import path, value is the import paths it imports.
+	packages map[string][]string
+
+	// importedBy is the reverse-dependency index of the workspace. It's ready once indexed is closed.
+	// Map key is repo root, value is sorted import paths of packages outside of the repo that import it.
+	importedBy map[string][]string
+	indexed    chan struct{}
+
+	// rateLimit is the remote API rate limit that presenting is paused on, until it resets.
+	rateLimitMu sync.Mutex
+	rateLimit   presenter.RateLimit
 
 	newObserver chan observerRequest
 	observers   map[chan *RepoPresentation]struct{}
@@ -61,8 +101,102 @@ type observerRequest struct {
 type RepoPresentation struct {
 	Repo         *gps.Repo
 	Presentation *presenter.Presentation
+	Analysis     Analysis
 
 	UpdateState UpdateState
+
+	// PreviousRevision is the local revision before the update.
+	// It's set once the update is completed, so it can be reverted.
+	PreviousRevision string
+
+	// Verification is the result of verifying the update once it's completed.
+	// Nil means it wasn't verified.
+	Verification *Verification
+}
+
+// Verification is the result of verifying a completed update
+// by building or testing Go packages affected by it.
+type Verification struct {
+	Command    string // Command that was run, e.g., "go build github.com/foo/bar".
+	Error      string // Error that verification failed with. Empty means success.
+	Output     string // Output of the command.
+	RolledBack bool   // The update was rolled back because verification failed.
+}
+
+// Analysis is the result of analyzing an update of a repository.
+type Analysis struct {
+	// ImportedBy are import paths of Go packages in the workspace,
+	// outside of the repository, that import packages in the repository.
+	// Updates are presented without waiting for all of the workspace to be found,
+	// so it's filled in once the reverse-dependency index is ready, and the
+	// repo presentation is then sent to observers again. It's protected by Packages.
+	ImportedBy []string
+
+	// Diverged describes local commits that aren't on the remote default branch,
+	// if the repository has diverged from it. Nil means it hasn't diverged,
+	// or that it couldn't be determined.
+	Diverged *Divergence
+}
+
+// Divergence describes how the local default branch of a repository
+// diverged from the remote default branch.
+type Divergence struct {
+	MergeBase    string   // Best common ancestor of local and remote revisions.
+	LocalCommits []Commit // Local commits that aren't on the remote default branch, newest first.
+}
+
+// Commit is a commit in a repository.
+type Commit struct {
+	Revision string
+	Message  string // First line of the commit message.
+}
+
+// Filter decides whether an update of a repository with local and remote revisions
+// already populated should be presented. It returns a non-empty reason
+// for why the update should be skipped, or empty string if it should be presented.
+type Filter func(repo *gps.Repo) (reason string)
+
+// URLRewriter rewrites a remote repository URL to its canonical form,
+// e.g., by mapping a URL of a mirror to the URL of the repository it mirrors.
+type URLRewriter func(url string) string
+
+// RepoRootResolver resolves the repository root of a Go package with import path,
+// like vcs.RepoRootForImportPath does.
+type RepoRootResolver func(importPath string) (*vcs.RepoRoot, error)
+
+// ForkComparer compares revision of a fork with upstreamRevision of the canonical upstream
+// repository at upstreamURL, whose Go packages have import paths under root, without
+// fetching commits into the local repository, e.g., via the API of its hosting service.
+// It returns how many upstream commits revision doesn't contain, or ok false
+// if it can't compare them, e.g., because the upstream's hosting service isn't supported.
+type ForkComparer func(root, upstreamURL, revision, upstreamRevision string) (behind int, ok bool, err error)
+
+// KnownState is the state of a repository that was fetched from its remote,
+// and the presentation of its update, if any.
+type KnownState struct {
+	Branch   string        // Remote branch that updates are computed against.
+	Revision string        // Revision of the remote branch.
+	Tracked  bool          // Branch is the upstream of the checked out local branch.
+	RepoURL  string        // Repository URL, as determined dynamically from the import path.
+	Upstream *gps.Upstream // Canonical upstream repository, if the repository is cloned from a fork.
+
+	// Presentation is the presentation of the update from LocalRevision to Revision.
+	// It's only remembered if presenting succeeded, so its Error is always nil.
+	// Nil means the update wasn't presented, e.g., because the repository was up to date.
+	Presentation  *presenter.Presentation
+	LocalRevision string
+
+	Time time.Time // Time the remote state was fetched at.
+}
+
+// StateStore remembers the last-known state of repositories across runs,
+// so it can be used when their remotes can't be reached.
+type StateStore interface {
+	// LastKnown returns the last-known state of repository with root, if any.
+	LastKnown(root string) (KnownState, bool)
+
+	// Remember remembers s as the last-known state of repository with root.
+	Remember(root string, s KnownState)
 }
 
 // UpdateState represents the state of an update.
@@ -84,7 +218,8 @@ const (
 // NewPipeline creates a Pipeline with working directory wd.
 // Working directory is used to resolve relative import paths.
 //
-// First, available presenters should be registered via RegisterPresenter.
+// First, available presenters and filters should be registered via RegisterPresenter
+// and RegisterFilter.
 // Then Go packages can be added via various means. Call Done once done adding.
 // Processing begins as soon as Go packages are added to the pipeline.
 // Results can be accessed via RepoPresentations at any time, as often as needed.
@@ -98,10 +233,12 @@ func NewPipeline(wd string) *Pipeline {
 		repositories:        make(chan LocalRepo, 64),
 		subrepos:            make(chan Subrepo, 64),
 		unique:              make(chan *gps.Repo, 64),
-		processedFiltered:   make(chan *gps.Repo, 64),
+		processedFiltered:   make(chan *RepoPresentation, 64),
 		presented:           make(chan *RepoPresentation, 64),
 
-		repos: make(map[string]*gps.Repo),
+		repos:    make(map[string]*gps.Repo),
+		packages: make(map[string][]string),
+		indexed:  make(chan struct{}),
 
 		newObserver: make(chan observerRequest),
 		observers:   make(map[chan *RepoPresentation]struct{}),
@@ -140,8 +277,9 @@ func NewPipeline(wd string) *Pipeline {
 	//
 	// The goal of processing in stage 1 is to take in diverse possible inputs
 	// and convert them into a unique set of repositories for further processing by next stages.
-	// When finished, all unique repositories are sent to p.unique channel
-	// and the channel is closed.
+	// Go packages found in local repositories are recorded along the way.
+	// When finished, all unique repositories are sent to p.unique channel,
+	// the reverse-dependency index is built, and the channel is closed.
 	{
 		var wg0 sync.WaitGroup
 		for range iter.N(8) {
@@ -174,6 +312,7 @@ func NewPipeline(wd string) *Pipeline {
 			wg2.Wait()
 			wg3.Wait()
 			wg4.Wait()
+			p.indexImporters()
 			close(p.unique)
 		}()
 	}
@@ -202,6 +341,7 @@ func NewPipeline(wd string) *Pipeline {
 	// We talk to remote APIs to fill in the missing presentation details
 	// that are not available from VCS (unless we fetch commits, but we choose not to that).
 	// Primarily, we get the commit messages for all the new commits that are available.
+	// If batch presenters are registered, repositories are presented in batches.
 	// When finished, all repositories complete with full presentation information
 	// are sent to p.presented channel and the channel is closed.
 	{
@@ -227,6 +367,105 @@ func (p *Pipeline) RegisterPresenter(pr presenter.Presenter) {
 	p.presenters = append(p.presenters, pr)
 }
 
+// RegisterEnricher registers an enricher.
+// Enrichers are consulted after presenting, in the same order that they were registered.
+func (p *Pipeline) RegisterEnricher(e presenter.Enricher) {
+	p.enrichers = append(p.enrichers, e)
+}
+
+// RegisterFilter registers a filter.
+// Filters are consulted after an update is found, in the same order that they were registered.
+func (p *Pipeline) RegisterFilter(f Filter) {
+	p.filters = append(p.filters, f)
+}
+
+// RegisterURLRewriter registers a URL rewriter. Local remote URLs and repo URLs
+// inferred from import paths are rewritten by all URL rewriters, in the same order
+// that they were registered, when they're compared or handed to presenters.
+// Network operations always use the original URLs.
+func (p *Pipeline) RegisterURLRewriter(rw URLRewriter) {
+	p.urlRewriters = append(p.urlRewriters, rw)
+}
+
+// ResolveRepoRootsWith makes the pipeline resolve repository roots with rr,
+// e.g., to cache them, rather than with vcs.RepoRootForImportPath.
+// It must be called before Go packages are added.
+func (p *Pipeline) ResolveRepoRootsWith(rr RepoRootResolver) {
+	p.repoRootResolver = rr
+}
+
+// RememberStateIn makes the pipeline remember the state of repos in s once it's fetched
+// from their remotes, and use the last-known state from s when remotes can't be reached,
+// rather than skip the repos. Their Repo.Remote.LastKnown is then set to the time
+// the state was fetched at, and their last-known presentations are used, if any.
+// It must be called before Go packages are added.
+func (p *Pipeline) RememberStateIn(s StateStore) {
+	p.stateStore = s
+}
+
+// Offline makes the pipeline use the last-known state of repos remembered in the state store
+// set with RememberStateIn, rather than fetch it from their remotes. Repos without
+// last-known state are skipped.
+// It must be called before Go packages are added.
+func (p *Pipeline) Offline() {
+	p.offline = true
+}
+
+// PresentDirty makes the pipeline present updates of repos with dirty working trees,
+// rather than skip them. Their Repo.Local.Status is populated with the working tree status.
+// It must be called before Go packages are added.
+func (p *Pipeline) PresentDirty() {
+	p.presentOpts.Dirty = true
+}
+
+// PresentDiverged makes the pipeline present updates of repos whose local default branch
+// has commits that aren't on the remote default branch, rather than skip them.
+// Their Repo.Local.Diverged is set to true.
+// It must be called before Go packages are added.
+func (p *Pipeline) PresentDiverged() {
+	p.presentOpts.Diverged = true
+}
+
+// PresentForks makes the pipeline present updates of git repos cloned from a fork
+// of the repository at the import path, rather than skip them. Updates of the fork
+// are presented, as well as updates of the canonical upstream repository
+// that the fork doesn't have. Their Repo.Remote.Upstream is populated.
+// It must be called before Go packages are added.
+func (p *Pipeline) PresentForks() {
+	p.presentOpts.Forks = true
+}
+
+// CompareForksWith makes the pipeline determine how far forks are behind their upstream
+// with fc, when upstream commits aren't available in the local repository.
+// It must be called before Go packages are added.
+func (p *Pipeline) CompareForksWith(fc ForkComparer) {
+	p.forkComparer = fc
+}
+
+// FetchForks makes the pipeline fetch upstream commits into the local repositories of forks,
+// when they're needed to determine how far the forks are behind, and the fork comparer
+// can't compare them. Fetching modifies the local repositories.
+// It must be called before Go packages are added.
+func (p *Pipeline) FetchForks() {
+	p.fetchForks = true
+}
+
+// TrackUpstream makes the pipeline compute updates of git repos against the upstream
+// of the checked out local branch, rather than the remote default branch, when they differ.
+// Only upstream branches on the origin remote are tracked. Their Repo.Remote.Tracked is set to true,
+// and Repo.Local.Branch is set to the local branch, whose name may differ from the upstream's.
+// It must be called before Go packages are added.
+func (p *Pipeline) TrackUpstream() {
+	p.trackUpstream = true
+}
+
+// RegisterBatchPresenter registers a batch presenter.
+// Batch presenters are consulted before presenters, in the same order that they were registered.
+// Repos are handed to them in batches of up to presentBatchSize repos.
+func (p *Pipeline) RegisterBatchPresenter(bp presenter.BatchPresenter) {
+	p.batchPresenters = append(p.batchPresenters, bp)
+}
+
 // AddImportPath adds a package with specified import path for processing.
 func (p *Pipeline) AddImportPath(importPath string) {
 	p.importPaths <- importPath
@@ -307,6 +546,8 @@ func (p *Pipeline) AddPresented(r *RepoPresentation) {
 // sent, the channel will be closed. Therefore, iterating over
 // the channel may block until all processing is done, but it
 // will effectively return all repo presentations as soon as possible.
+// A repo presentation that is sent before the workspace packages that
+// import it are known is sent again once its Analysis.ImportedBy is filled in.
 //
 // It's safe to call RepoPresentations at any time and concurrently
 // to get multiple such channels.
@@ -317,6 +558,7 @@ func (p *Pipeline) RepoPresentations() <-chan *RepoPresentation {
 }
 
 func (p *Pipeline) run() {
+	indexed := p.indexed
 Outer:
 	for {
 		select {
@@ -324,11 +566,22 @@ Outer:
 		case repoPresentation, ok := <-p.presented:
 			// We're done streaming.
 			if !ok {
+				if indexed != nil {
+					// The index is always ready before presenting is done,
+					// but it may not have been handled yet.
+					<-indexed
+					p.sendImportedBy()
+				}
 				break Outer
 			}
 
 			// Append repoPresentation to current list.
 			p.Packages.Lock()
+			if indexed == nil {
+				if importedBy, ok := p.importedBy[repoPresentation.Repo.Root]; ok {
+					repoPresentation.Analysis.ImportedBy = importedBy
+				}
+			}
 			switch repoPresentation.UpdateState {
 			case Available, Updating:
 				p.Packages.Active = append(p.Packages.Active, repoPresentation)
@@ -343,6 +596,10 @@ Outer:
 				// TODO: If an observer isn't listening, this will block. Should we defend against that here?
 				ch <- repoPresentation
 			}
+		// Reverse-dependency index is ready.
+		case <-indexed:
+			indexed = nil
+			p.sendImportedBy()
 		// New observer request.
 		case req := <-p.newObserver:
 			p.Packages.Lock()
@@ -385,6 +642,31 @@ Outer:
 	}
 }
 
+// sendImportedBy fills in the workspace packages that import each repo
+// presented so far, and sends the ones that have any to all existing observers again.
+// It must be called from run after the reverse-dependency index is ready.
+func (p *Pipeline) sendImportedBy() {
+	var updated []*RepoPresentation
+	p.Packages.Lock()
+	for _, repoPresentations := range [][]*RepoPresentation{p.Packages.Active, p.Packages.History} {
+		for _, repoPresentation := range repoPresentations {
+			importedBy, ok := p.importedBy[repoPresentation.Repo.Root]
+			if !ok {
+				continue
+			}
+			repoPresentation.Analysis.ImportedBy = importedBy
+			updated = append(updated, repoPresentation)
+		}
+	}
+	p.Packages.Unlock()
+
+	for _, repoPresentation := range updated {
+		for ch := range p.observers {
+			ch <- repoPresentation
+		}
+	}
+}
+
 // importPathWorker sends unique repositories to phase 2.
 func (p *Pipeline) importPathWorker(wg *sync.WaitGroup) {
 	defer wg.Done()
@@ -411,6 +693,8 @@ func (p *Pipeline) importPathWorker(wg *sync.WaitGroup) {
 			continue
 		}
 
+		p.addPackage(importPath, bpkg.Dir)
+
 		var repo *gps.Repo
 		p.reposMu.Lock()
 		if _, ok := p.repos[root]; !ok {
@@ -421,12 +705,8 @@ func (p *Pipeline) importPathWorker(wg *sync.WaitGroup) {
 				VCS:  vcs,
 				Path: bpkg.Dir,
 				Cmd:  vcsCmd,
-
-				// TODO: Maybe keep track of import paths inside, etc.
 			}
 			p.repos[root] = repo
-			//} else {
-			// TODO: Maybe keep track of import paths inside, etc.
 		}
 		p.reposMu.Unlock()
 
@@ -443,7 +723,7 @@ func (p *Pipeline) importPathRevisionWorker(wg *sync.WaitGroup) {
 	for ipr := range p.importPathRevisions {
 		// Determine repo root.
 		// This is potentially somewhat slow.
-		rr, err := vcs.RepoRootForImportPath(ipr.importPath, false)
+		rr, err := p.resolveRepoRoot(ipr.importPath)
 		if err != nil {
 			log.Printf("failed to dynamically determine repo root for %v: %v\n", ipr.importPath, err)
 			continue
@@ -483,7 +763,7 @@ func (p *Pipeline) rootRevisionLatestWorker(wg *sync.WaitGroup) {
 	for rrl := range p.rootRevisionLatests {
 		// Determine repo root.
 		// This is potentially somewhat slow.
-		rr, err := vcs.RepoRootForImportPath(rrl.root, false)
+		rr, err := p.resolveRepoRoot(rrl.root)
 		if err != nil {
 			log.Printf("failed to dynamically determine repo root for %v: %v\n", rrl.root, err)
 			continue
@@ -542,6 +822,10 @@ func (p *Pipeline) repositoriesWorker(wg *sync.WaitGroup) {
 		if repo != nil {
 			p.unique <- repo
 		}
+
+		// Record Go packages after sending off the repo, so that finding them
+		// doesn't hold back checking it for updates.
+		p.addPackages(root, r.Path)
 	}
 }
 
@@ -551,7 +835,7 @@ func (p *Pipeline) subreposWorker(wg *sync.WaitGroup) {
 	for r := range p.subrepos {
 		// Determine repo root.
 		// This is potentially somewhat slow.
-		rr, err := vcs.RepoRootForImportPath(r.Root, false)
+		rr, err := p.resolveRepoRoot(r.Root)
 		if err != nil {
 			log.Printf("failed to dynamically determine repo root for %v: %v\n", r.Root, err)
 			continue
@@ -590,53 +874,394 @@ func (p *Pipeline) processFilterWorker(wg *sync.WaitGroup) {
 		// This is slow because it requires a network operation.
 		switch {
 		case r.VCS != nil:
-			var err error
-			r.Remote.Branch, r.Remote.Revision, err = r.VCS.RemoteBranchAndRevision(r.Path)
-			if err != nil {
-				log.Printf("skipping %q because of remote error:\n\t%v\n", r.Root, err)
+			if !p.remoteState(r, func() (string, string, error) { return r.VCS.RemoteBranchAndRevision(r.Path) }) {
 				continue
 			}
+			switch {
+			case p.trackUpstream && r.Remote.LastKnown.IsZero():
+				localBranch, branch, revision, err := upstreamBranchAndRevision(r)
+				switch {
+				case err != nil:
+					log.Printf("skipping %q because of error determining upstream branch:\n\t%v\n", r.Root, err)
+					continue
+				case branch != "" && (branch != r.Remote.Branch || localBranch != branch):
+					r.Local.Branch = localBranch
+					r.Remote.Branch, r.Remote.Revision, r.Remote.Tracked = branch, revision, true
+				}
+			case r.Remote.Tracked:
+				// Last-known remote state doesn't include the local branch, so determine it locally.
+				localBranch, branch, err := upstreamBranch(r)
+				switch {
+				case err != nil:
+					log.Printf("skipping %q because of error determining upstream branch:\n\t%v\n", r.Root, err)
+					continue
+				case branch != r.Remote.Branch:
+					log.Printf("skipping %q because checked out branch doesn't track last-known upstream branch %q anymore\n", r.Root, r.Remote.Branch)
+					continue
+				}
+				r.Local.Branch = localBranch
+			}
 
 			if r.Local.Revision == "" {
-				if rev, err := r.VCS.LocalRevision(r.Path, r.Remote.Branch); err == nil {
+				if rev, err := r.VCS.LocalRevision(r.Path, localBranchName(r)); err == nil {
 					r.Local.Revision = rev
 				}
 			}
 			if ru, err := r.VCS.RemoteURL(r.Path); err == nil {
 				r.Local.RemoteURL = ru
 			}
-			if rr, err := vcs.RepoRootForImportPath(r.Root, false); err == nil {
+			if !r.Remote.LastKnown.IsZero() {
+				// Remote state is already populated from last-known state.
+				break
+			}
+			if rr, err := p.resolveRepoRoot(r.Root); err == nil {
 				r.Remote.RepoURL = rr.Repo
 			} else {
 				log.Printf("failed to dynamically determine repo root for %v: %v\n", r.Root, err)
 			}
+			if p.presentOpts.Forks && r.Remote.RepoURL != "" && !p.equalRepoURLs(r.Local.RemoteURL, r.Remote.RepoURL) {
+				// This is slow because it requires network operations.
+				upstream, err := p.forkUpstream(r)
+				if err != nil {
+					log.Printf("skipping %q because of error determining fork upstream:\n\t%v\n", r.Root, err)
+					continue
+				}
+				r.Remote.Upstream = upstream
+			}
 		case r.RemoteVCS != nil:
-			var err error
-			r.Remote.Branch, r.Remote.Revision, err = r.RemoteVCS.RemoteBranchAndRevision(r.RemoteURL)
-			if err != nil {
-				log.Printf("skipping %q because of remote error:\n\t%v\n", r.Root, err)
+			if !p.remoteState(r, func() (string, string, error) { return r.RemoteVCS.RemoteBranchAndRevision(r.RemoteURL) }) {
 				continue
 			}
 		default:
 			// Do nothing. If both r.VCS and r.RemoteVCS are nil, then we expect
 			// the Local and Remote structs to already be populated.
 		}
+		if (r.VCS != nil || r.RemoteVCS != nil) && r.Remote.LastKnown.IsZero() {
+			p.rememberRemoteState(r)
+		}
 
-		if ok, reason := shouldPresentUpdate(r); !ok {
+		if ok, reason := p.shouldPresentUpdate(r); !ok {
 			if reason != "" {
 				log.Printf("skipping %q because:\n\t%v\n", r.Root, reason)
 			}
 			continue
 		}
+		if reason := p.filter(r); reason != "" {
+			log.Printf("skipping %q because:\n\t%v\n", r.Root, reason)
+			continue
+		}
+
+		rp := &RepoPresentation{Repo: r}
+		if r.Local.Diverged {
+			rp.Analysis.Diverged = divergence(r)
+		}
+		p.processedFiltered <- rp
+	}
+}
+
+// remoteState populates the remote branch and revision of r with fetch.
+// In offline mode, or if fetch fails, they're populated from the last-known state instead.
+// It reports whether the remote state of r is available.
+func (p *Pipeline) remoteState(r *gps.Repo, fetch func() (branch, revision string, err error)) bool {
+	if p.offline {
+		if !p.useLastKnownState(r) {
+			log.Printf("skipping %q because offline mode is enabled, and its remote state isn't known\n", r.Root)
+			return false
+		}
+		return true
+	}
+	var err error
+	r.Remote.Branch, r.Remote.Revision, err = fetch()
+	if err == nil {
+		return true
+	}
+	if !p.useLastKnownState(r) {
+		log.Printf("skipping %q because of remote error:\n\t%v\n", r.Root, err)
+		return false
+	}
+	log.Printf("using remote state of %q fetched %v, because of remote error:\n\t%v\n", r.Root, humanize.Time(r.Remote.LastKnown), err)
+	return true
+}
+
+// useLastKnownState populates the remote state of r from its last-known state.
+// It reports whether r has last-known state.
+func (p *Pipeline) useLastKnownState(r *gps.Repo) bool {
+	if p.stateStore == nil {
+		return false
+	}
+	// Last-known state of a tracked upstream branch can't be used
+	// if upstream branches aren't tracked anymore.
+	s, ok := p.stateStore.LastKnown(r.Root)
+	if !ok || (s.Tracked && !p.trackUpstream) {
+		return false
+	}
+	r.Remote.Branch, r.Remote.Revision, r.Remote.Tracked = s.Branch, s.Revision, s.Tracked
+	r.Remote.RepoURL = s.RepoURL
+	if p.presentOpts.Forks {
+		r.Remote.Upstream = s.Upstream
+	}
+	r.Remote.LastKnown = s.Time
+	return true
+}
+
+// rememberRemoteState remembers the current remote state of r in the state store, if any.
+// The last-known presentation is kept if it's of the same remote revision.
+func (p *Pipeline) rememberRemoteState(r *gps.Repo) {
+	if p.stateStore == nil {
+		return
+	}
+	s := KnownState{
+		Branch:   r.Remote.Branch,
+		Revision: r.Remote.Revision,
+		Tracked:  r.Remote.Tracked,
+		RepoURL:  r.Remote.RepoURL,
+		Upstream: r.Remote.Upstream,
+		Time:     time.Now().UTC(),
+	}
+	if old, ok := p.stateStore.LastKnown(r.Root); ok && old.Revision == s.Revision {
+		s.Presentation, s.LocalRevision = old.Presentation, old.LocalRevision
+	}
+	p.stateStore.Remember(r.Root, s)
+}
+
+// rememberPresentation remembers the presentation of rp in the state store, if any,
+// as long as it's current and presenting succeeded.
+func (p *Pipeline) rememberPresentation(rp *RepoPresentation) {
+	if p.stateStore == nil || !rp.Repo.Remote.LastKnown.IsZero() || rp.Presentation.Error != nil {
+		return
+	}
+	s, ok := p.stateStore.LastKnown(rp.Repo.Root)
+	if !ok || s.Revision != rp.Repo.Remote.Revision {
+		return
+	}
+	presentation := *rp.Presentation
+	presentation.RateLimit = nil // Rate limit state isn't meaningful later.
+	s.Presentation, s.LocalRevision = &presentation, p.presenterRepo(rp).LocalRevision
+	p.stateStore.Remember(rp.Repo.Root, s)
+}
+
+// lastKnownPresentation returns the last-known presentation of rp, whose remote state is last-known.
+// If there's no last-known presentation of the update, a generic presentation is returned.
+func (p *Pipeline) lastKnownPresentation(rp *RepoPresentation) *presenter.Presentation {
+	s, ok := p.stateStore.LastKnown(rp.Repo.Root)
+	if ok && s.Presentation != nil && s.Revision == rp.Repo.Remote.Revision && s.LocalRevision == p.presenterRepo(rp).LocalRevision {
+		presentation := *s.Presentation
+		return &presentation
+	}
+	return &presenter.Presentation{
+		HomeURL:  "https://" + rp.Repo.Root,
+		ImageURL: "https://github.com/images/gravatars/gravatar-user-420.png",
+		Error:    errors.New("changes are not known, because the remote can't be reached"),
+	}
+}
+
+// upstreamBranchAndRevision returns the checked out local branch of git repo r,
+// the name of its upstream branch, and the upstream's remote revision. It returns
+// empty branch if r isn't a git repo, or if the local branch doesn't have an upstream
+// on the origin remote. The names of the local and upstream branches may differ.
+func upstreamBranchAndRevision(r *gps.Repo) (localBranch, branch, revision string, err error) {
+	localBranch, branch, err = upstreamBranch(r)
+	if err != nil || branch == "" {
+		return "", "", "", err
+	}
+	out, err := gitOutput(r.Path, "ls-remote", "origin", "refs/heads/"+branch)
+	if err != nil {
+		return "", "", "", err
+	}
+	revision = strings.Fields(out + " ")[0]
+	if revision == "" {
+		return "", "", "", fmt.Errorf("upstream branch %q doesn't exist on remote", branch)
+	}
+	return localBranch, branch, revision, nil
+}
+
+// upstreamBranch returns the checked out local branch of git repo r, and the name
+// of its upstream branch, as configured locally. It returns empty branch
+// if r isn't a git repo, or if the local branch doesn't have an upstream on the origin remote.
+func upstreamBranch(r *gps.Repo) (localBranch, branch string, err error) {
+	if r.Cmd == nil || r.Cmd.Cmd != "git" {
+		return "", "", nil
+	}
+	localBranch, err = r.VCS.Branch(r.Path)
+	if err != nil {
+		return "", "", err
+	}
+	// Errors mean the config value is not set, i.e., there's no upstream.
+	remote, _ := gitOutput(r.Path, "config", "branch."+localBranch+".remote")
+	merge, _ := gitOutput(r.Path, "config", "branch."+localBranch+".merge")
+	if remote != "origin" || !strings.HasPrefix(merge, "refs/heads/") {
+		return "", "", nil
+	}
+	return localBranch, strings.TrimPrefix(merge, "refs/heads/"), nil
+}
+
+// localBranchName returns the name of the local branch of repo that corresponds to its remote branch.
+func localBranchName(repo *gps.Repo) string {
+	if repo.Local.Branch != "" {
+		return repo.Local.Branch
+	}
+	return repo.Remote.Branch
+}
+
+// forkUpstream returns the canonical upstream of git repo r, whose origin remote is a fork
+// of the repository at r.Remote.RepoURL. The upstream revision is determined with git ls-remote,
+// and how far the fork is behind from commits available in the local repository, or else
+// with the fork comparer. Upstream commits are only fetched into the local repository
+// if FetchForks was called. It returns nil if r isn't a git repo, or if its remote revision
+// has no history in common with the upstream revision, since then the origin remote
+// isn't a fork, but an unrelated repository.
+func (p *Pipeline) forkUpstream(r *gps.Repo) (*gps.Upstream, error) {
+	if r.Cmd == nil || r.Cmd.Cmd != "git" {
+		return nil, nil
+	}
+	out, err := gitOutput(r.Path, "ls-remote", "--symref", r.Remote.RepoURL, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	var u gps.Upstream
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 3 && fields[0] == "ref:" && fields[2] == "HEAD":
+			u.Branch = strings.TrimPrefix(fields[1], "refs/heads/")
+		case len(fields) == 2 && fields[1] == "HEAD":
+			u.Revision = fields[0]
+		}
+	}
+	if u.Branch == "" || u.Revision == "" {
+		return nil, fmt.Errorf("default branch of %v not found", r.Remote.RepoURL)
+	}
+
+	if !hasCommits(r.Path, r.Remote.Revision, u.Revision) {
+		if p.forkComparer != nil {
+			// This is slow because it requires a network operation.
+			behind, ok, err := p.forkComparer(r.Root, p.rewriteURL(r.Remote.RepoURL), r.Remote.Revision, u.Revision)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				u.Behind = behind
+				return &u, nil
+			}
+		}
+		if !p.fetchForks {
+			return nil, fmt.Errorf("commits of upstream %v aren't available locally, and it can't be compared with the fork otherwise", r.Remote.RepoURL)
+		}
+		if _, err := gitOutput(r.Path, "fetch", "--quiet", r.Remote.RepoURL, u.Branch); err != nil {
+			return nil, err
+		}
+		if !hasCommits(r.Path, r.Remote.Revision) {
+			if _, err := gitOutput(r.Path, "fetch", "--quiet", "origin", r.Remote.Branch); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if mergeBase, err := gitOutput(r.Path, "merge-base", r.Remote.Revision, u.Revision); err != nil || mergeBase == "" {
+		// No common ancestor, so it's not a fork.
+		return nil, nil
+	}
+	behind, err := gitOutput(r.Path, "rev-list", "--count", r.Remote.Revision+".."+u.Revision)
+	if err != nil {
+		return nil, err
+	}
+	u.Behind, err = strconv.Atoi(behind)
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// hasCommits reports whether git repository at dir has all of commits revs.
+func hasCommits(dir string, revs ...string) bool {
+	for _, rev := range revs {
+		if _, err := gitOutput(dir, "cat-file", "-e", rev+"^{commit}"); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// divergence returns the local commits of diverged git repo r that aren't on
+// the remote default branch. Only commits already available in the local repository
+// are used, and fetching is left to the update, which rebases. It returns nil if r isn't
+// a git repo, or if the remote revision isn't available locally.
+func divergence(r *gps.Repo) *Divergence {
+	if r.Cmd == nil || r.Cmd.Cmd != "git" {
+		return nil
+	}
+	if !hasCommits(r.Path, r.Remote.Revision) {
+		// Remote revision isn't available locally, so the merge base can't be determined.
+		return nil
+	}
+	mergeBase, err := gitOutput(r.Path, "merge-base", r.Local.Revision, r.Remote.Revision)
+	if err != nil {
+		log.Printf("error finding merge base of %q: %v\n", r.Root, err)
+		return nil
+	}
+	d := &Divergence{MergeBase: mergeBase}
+	out, err := gitOutput(r.Path, "log", "--format=%H %s", mergeBase+".."+r.Local.Revision)
+	if err != nil {
+		log.Printf("error listing local commits of %q: %v\n", r.Root, err)
+		return nil
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if line == "" {
+			continue
+		}
+		revision, message, _ := strings.Cut(line, " ")
+		d.LocalCommits = append(d.LocalCommits, Commit{Revision: revision, Message: message})
+	}
+	return d
+}
+
+// gitOutput runs git with args in dir, and returns its output with surrounding whitespace trimmed.
+func gitOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// resolveRepoRoot resolves the repository root of a Go package with import path.
+func (p *Pipeline) resolveRepoRoot(importPath string) (*vcs.RepoRoot, error) {
+	if p.repoRootResolver != nil {
+		return p.repoRootResolver(importPath)
+	}
+	return vcs.RepoRootForImportPath(importPath, false)
+}
+
+// rewriteURL rewrites url by all registered URL rewriters.
+func (p *Pipeline) rewriteURL(url string) string {
+	for _, rw := range p.urlRewriters {
+		url = rw(url)
+	}
+	return url
+}
 
-		p.processedFiltered <- r
+// equalRepoURLs reports whether repo URLs u1 and u2 are equal,
+// after being rewritten by all registered URL rewriters.
+func (p *Pipeline) equalRepoURLs(u1, u2 string) bool {
+	return status.EqualRepoURLs(p.rewriteURL(u1), p.rewriteURL(u2))
+}
+
+// filter returns the reason of the first registered filter
+// that skips an update of repo, or empty string if none do.
+func (p *Pipeline) filter(repo *gps.Repo) (reason string) {
+	for _, f := range p.filters {
+		if reason := f(repo); reason != "" {
+			return reason
+		}
 	}
+	return ""
 }
 
 // shouldPresentUpdate reports if the given goPackage should be presented as an available update.
-// It checks that the Go package is on default branch, does not have a dirty working tree, and does not have the remote revision.
+// It checks that the Go package is on default branch (or the tracked upstream branch), does not have a dirty working tree, and does not have the remote revision.
+// Options in p.presentOpts allow presenting some of the updates that would otherwise be skipped.
 // It returns a non-empty reason for why an update should be skipped, or empty string if it's not interesting (e.g., repository is up to date).
-func shouldPresentUpdate(repo *gps.Repo) (ok bool, reason string) {
+func (p *Pipeline) shouldPresentUpdate(repo *gps.Repo) (ok bool, reason string) {
+	opt := p.presentOpts
+
 	// Ensure sufficient remote information is available, otherwise we can't present updates.
 	if repo.Remote.RepoURL == "" {
 		return false, "repository URL (as determined dynamically from the import path) is empty"
@@ -652,9 +1277,10 @@ func shouldPresentUpdate(repo *gps.Repo) (ok bool, reason string) {
 	// reasons first.
 	switch {
 	case repo.VCS != nil:
-		// Local remote URL should match Repo URL derived from import path.
-		// This is the very first thing to verify, because it affects default branch.
-		if !status.EqualRepoURLs(repo.Local.RemoteURL, repo.Remote.RepoURL) {
+		// Local remote URL should match Repo URL derived from import path,
+		// unless it's a fork of it. This is the very first thing to verify,
+		// because it affects default branch.
+		if repo.Remote.Upstream == nil && !p.equalRepoURLs(repo.Local.RemoteURL, repo.Remote.RepoURL) {
 			return false, "remote URL doesn't match repo URL inferred from import path:" +
 				fmt.Sprintf("\n		  (actual) %s", repo.Local.RemoteURL) +
 				fmt.Sprintf("\n		(expected) %s", status.FormatRepoURL(repo.Local.RemoteURL, repo.Remote.RepoURL))
@@ -665,7 +1291,7 @@ func shouldPresentUpdate(repo *gps.Repo) (ok bool, reason string) {
 		if err != nil {
 			return false, "error determining local branch:\n" + err.Error()
 		}
-		if localBranch != repo.Remote.Branch {
+		if localBranch != localBranchName(repo) {
 			return false, fmt.Sprintf("local branch %q doesn't match remote branch %q", localBranch, repo.Remote.Branch)
 		}
 
@@ -673,7 +1299,7 @@ func shouldPresentUpdate(repo *gps.Repo) (ok bool, reason string) {
 		// TODO: Consider taking care of this difference in remote URLs earlier, inside, e.g., subreposWorker. But need to make that play nicely with the updaters; see TODO at bottom of gps.Repo struct.
 		//
 		// Local remote URL, if set, should match Repo URL derived from import path.
-		if repo.Local.RemoteURL != "" && !status.EqualRepoURLs(repo.Local.RemoteURL, repo.Remote.RepoURL) {
+		if repo.Local.RemoteURL != "" && !p.equalRepoURLs(repo.Local.RemoteURL, repo.Remote.RepoURL) {
 			return false, "remote URL doesn't match repo URL inferred from import path:" +
 				fmt.Sprintf("\n		  (actual) %s", repo.Local.RemoteURL) +
 				fmt.Sprintf("\n		(expected) %s", status.FormatRepoURL(repo.Local.RemoteURL, repo.Remote.RepoURL))
@@ -686,6 +1312,10 @@ func shouldPresentUpdate(repo *gps.Repo) (ok bool, reason string) {
 
 	// Check if repo is already up to date.
 	if repo.Local.Revision == repo.Remote.Revision {
+		if u := repo.Remote.Upstream; u != nil && u.Behind > 0 {
+			// The fork is up to date, but upstream has updates that the fork doesn't.
+			return true, ""
+		}
 		// No reason provided because it's not worth mentioning.
 		return false, ""
 	}
@@ -696,7 +1326,7 @@ func shouldPresentUpdate(repo *gps.Repo) (ok bool, reason string) {
 		// Local default branch shouldn't contain remote commit.
 		// Otherwise, it means local revision is different because it's
 		// ahead of remote revision, rather than because there's an update.
-		localContainsRemoteRevision, err := repo.VCS.Contains(repo.Path, repo.Remote.Revision, repo.Remote.Branch)
+		localContainsRemoteRevision, err := repo.VCS.Contains(repo.Path, repo.Remote.Revision, localBranchName(repo))
 		if err != nil {
 			return false, "error determining if local default branch contains remote revision:\n" + err.Error()
 		}
@@ -713,7 +1343,10 @@ func shouldPresentUpdate(repo *gps.Repo) (ok bool, reason string) {
 		if err != nil {
 			return false, "error determining if remote default branch contains local revision:\n" + err.Error()
 		}
-		if !remoteContainsLocalRevision {
+		switch {
+		case !remoteContainsLocalRevision && opt.Diverged:
+			repo.Local.Diverged = true
+		case !remoteContainsLocalRevision:
 			return false, fmt.Sprintf("local revision %q is ahead of remote revision %q", repo.Local.Revision, repo.Remote.Revision)
 		}
 
@@ -722,7 +1355,10 @@ func shouldPresentUpdate(repo *gps.Repo) (ok bool, reason string) {
 		if err != nil {
 			return false, "error determining if working tree is dirty:\n" + err.Error()
 		}
-		if treeStatus != "" {
+		switch {
+		case treeStatus != "" && opt.Dirty:
+			repo.Local.Status = treeStatus
+		case treeStatus != "":
 			return false, "working tree is dirty:\n" + treeStatus
 		}
 	}
@@ -731,22 +1367,194 @@ func shouldPresentUpdate(repo *gps.Repo) (ok bool, reason string) {
 	return true, ""
 }
 
+// presentOptions are options for presenting updates that are otherwise skipped.
+type presentOptions struct {
+	Dirty    bool // Present repos with dirty working trees, populating Repo.Local.Status.
+	Diverged bool // Present repos with local commits that aren't on remote, setting Repo.Local.Diverged.
+	Forks    bool // Present repos cloned from forks, populating Repo.Remote.Upstream.
+}
+
 // presentWorker works with repos that should be displayed, creating a presentation for each.
+//
+// If a presenter reports that a remote API rate limit was reached, presenting is
+// paused until the rate limit resets, and the affected repos are presented again.
 func (p *Pipeline) presentWorker(wg *sync.WaitGroup) {
 	defer wg.Done()
-	for repo := range p.processedFiltered {
-		// This part might take a while.
-		presentation := p.present(presenter.Repo{
-			Root:           repo.Root,
-			RepoURL:        repo.Remote.RepoURL,
-			LocalRevision:  repo.Local.Revision,
-			RemoteRevision: repo.Remote.Revision,
-		})
-
-		p.presented <- &RepoPresentation{
-			Repo:         repo,
-			Presentation: presentation,
+	for {
+		rps := p.nextPresentBatch()
+		if len(rps) == 0 {
+			return
+		}
+
+		for attempt := 1; len(rps) > 0; attempt++ {
+			p.waitRateLimit()
+
+			// This part might take a while.
+			presentations := p.presentBatch(rps)
+
+			var retry []*RepoPresentation
+			for i, rp := range rps {
+				if rl := presentations[i].RateLimit; rl != nil && rl.Remaining == 0 {
+					p.setRateLimit(*rl)
+				}
+				if err, ok := presentations[i].Error.(*presenter.RateLimitError); ok && attempt < maxPresentAttempts {
+					p.setRateLimit(err.RateLimit)
+					retry = append(retry, rp)
+					continue
+				}
+
+				rp.Presentation = presentations[i]
+				p.rememberPresentation(rp)
+				p.presented <- rp
+			}
+			rps = retry
+		}
+	}
+}
+
+// maxPresentAttempts is the maximum number of times a repo is presented
+// when presentation fails due to a rate limit.
+const maxPresentAttempts = 3
+
+// rateLimitSlack is extra time to wait after a rate limit resets,
+// to allow for clock differences between local and remote machines.
+const rateLimitSlack = 5 * time.Second
+
+// setRateLimit pauses presenting until rate limit rl resets,
+// unless presenting is already paused until a later time.
+func (p *Pipeline) setRateLimit(rl presenter.RateLimit) {
+	p.rateLimitMu.Lock()
+	if rl.Reset.After(p.rateLimit.Reset) {
+		p.rateLimit = rl
+	}
+	p.rateLimitMu.Unlock()
+}
+
+// waitRateLimit blocks while presenting is paused on a rate limit.
+func (p *Pipeline) waitRateLimit() {
+	for {
+		p.rateLimitMu.Lock()
+		d := time.Until(p.rateLimit.Reset.Add(rateLimitSlack))
+		p.rateLimitMu.Unlock()
+		if d <= 0 {
+			return
 		}
+		time.Sleep(d)
+	}
+}
+
+// RateLimited reports whether presenting is paused because
+// a remote API rate limit was reached, and if so, which one.
+func (p *Pipeline) RateLimited() (rl presenter.RateLimit, ok bool) {
+	p.rateLimitMu.Lock()
+	defer p.rateLimitMu.Unlock()
+	if time.Now().After(p.rateLimit.Reset.Add(rateLimitSlack)) {
+		return presenter.RateLimit{}, false
+	}
+	return p.rateLimit, true
+}
+
+const (
+	// presentBatchSize is the maximum number of repos handed to batch presenters at once.
+	presentBatchSize = 25
+
+	// presentBatchDelay is how long to wait for more repos to fill a batch.
+	presentBatchDelay = 250 * time.Millisecond
+)
+
+// nextPresentBatch receives the next batch of repos to present from p.processedFiltered.
+// If there are no batch presenters, batches consist of a single repo.
+// It returns an empty batch when there are no more repos to present.
+func (p *Pipeline) nextPresentBatch() []*RepoPresentation {
+	rp, ok := <-p.processedFiltered
+	if !ok {
+		return nil
+	}
+	rps := []*RepoPresentation{rp}
+	if len(p.batchPresenters) == 0 {
+		return rps
+	}
+	timeout := time.After(presentBatchDelay)
+	for len(rps) < presentBatchSize {
+		select {
+		case rp, ok := <-p.processedFiltered:
+			if !ok {
+				return rps
+			}
+			rps = append(rps, rp)
+		case <-timeout:
+			return rps
+		}
+	}
+	return rps
+}
+
+// presentBatch returns presentations for repos of rps, in the same order.
+// Repos whose remote state is last-known get their last-known presentations.
+// For the rest, batch presenters are consulted first. The repos they couldn't present
+// are presented one by one via present. Then, enrichers add to all presentations.
+func (p *Pipeline) presentBatch(rps []*RepoPresentation) []*presenter.Presentation {
+	presentations := make([]*presenter.Presentation, len(rps))
+	for i, rp := range rps {
+		if !rp.Repo.Remote.LastKnown.IsZero() {
+			// Presenting requires the remote, so use the last-known presentation instead.
+			presentations[i] = p.lastKnownPresentation(rp)
+		}
+	}
+	for _, bp := range p.batchPresenters {
+		var (
+			indices []int // Indices of repos without a presentation yet.
+			rs      []presenter.Repo
+		)
+		for i, rp := range rps {
+			if presentations[i] == nil {
+				indices = append(indices, i)
+				rs = append(rs, p.presenterRepo(rp))
+			}
+		}
+		if len(rs) == 0 {
+			break
+		}
+		for j, presentation := range bp(context.Background(), rs) {
+			if j >= len(indices) {
+				// Batch presenter returned more presentations than repos. Ignore the extra ones.
+				break
+			}
+			presentations[indices[j]] = presentation
+		}
+	}
+	for i, rp := range rps {
+		if !rp.Repo.Remote.LastKnown.IsZero() {
+			continue
+		}
+		if presentations[i] == nil {
+			presentations[i] = p.present(p.presenterRepo(rp))
+		}
+		for _, enrich := range p.enrichers {
+			enrich(context.Background(), p.presenterRepo(rp), presentations[i])
+		}
+	}
+	return presentations
+}
+
+// presenterRepo returns the presenter input for repo of rp.
+// If the repo has diverged, changes are presented since the merge base,
+// since the local revision isn't on the remote. If the repo is an up to date fork,
+// upstream changes that the fork doesn't have are presented instead.
+func (p *Pipeline) presenterRepo(rp *RepoPresentation) presenter.Repo {
+	localRevision, remoteRevision := rp.Repo.Local.Revision, rp.Repo.Remote.Revision
+	if d := rp.Analysis.Diverged; d != nil && d.MergeBase != "" {
+		localRevision = d.MergeBase
+	}
+	if u := rp.Repo.Remote.Upstream; u != nil && rp.Repo.Local.Revision == rp.Repo.Remote.Revision {
+		localRevision, remoteRevision = rp.Repo.Remote.Revision, u.Revision
+	}
+	return presenter.Repo{
+		Root:           rp.Repo.Root,
+		RepoURL:        p.rewriteURL(rp.Repo.Remote.RepoURL),
+		LocalRevision:  localRevision,
+		RemoteRevision: remoteRevision,
+		Path:           rp.Repo.Path,
 	}
 }
 
@@ -0,0 +1,277 @@
+package workspace
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shurcooL/Go-Package-Store"
+	"github.com/shurcooL/Go-Package-Store/presenter"
+	"github.com/shurcooL/vcsstate"
+	"golang.org/x/tools/go/vcs"
+)
+
+func TestUpstreamBranchAndRevision(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git binary is not available:", err)
+	}
+
+	// Set up a remote repository with a release branch, and a local clone.
+	remote, local := t.TempDir(), t.TempDir()
+	git(t, remote, "init", "--quiet")
+	git(t, remote, "commit", "--quiet", "--allow-empty", "--message", "first")
+	git(t, remote, "branch", "release")
+	git(t, remote, "commit", "--quiet", "--allow-empty", "--message", "second")
+	git(t, local, "clone", "--quiet", remote, ".")
+	git(t, local, "branch", "unpushed")
+	git(t, local, "branch", "--quiet", "--track", "mybranch", "origin/release")
+	git(t, local, "checkout", "--quiet", "--track", "origin/release")
+	wantRevision := git(t, remote, "rev-parse", "release")
+
+	tests := []struct {
+		localBranch     string
+		wantLocalBranch string
+		wantBranch      string
+		wantRevision    string
+	}{
+		{localBranch: "release", wantLocalBranch: "release", wantBranch: "release", wantRevision: wantRevision},
+		{localBranch: "mybranch", wantLocalBranch: "mybranch", wantBranch: "release", wantRevision: wantRevision}, // Differently named upstream.
+		{localBranch: "unpushed", wantBranch: ""}, // No upstream.
+	}
+	for _, tc := range tests {
+		r := &gps.Repo{Path: local, Cmd: vcs.ByCmd("git"), VCS: branchVCS{branch: tc.localBranch}}
+		localBranch, branch, revision, err := upstreamBranchAndRevision(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if localBranch != tc.wantLocalBranch || branch != tc.wantBranch || revision != tc.wantRevision {
+			t.Errorf("%s: got %q, %q, %q, want %q, %q, %q", tc.localBranch, localBranch, branch, revision, tc.wantLocalBranch, tc.wantBranch, tc.wantRevision)
+		}
+	}
+}
+
+func TestShouldPresentUpdateTrackedBranch(t *testing.T) {
+	// Local branch mybranch tracks upstream branch release.
+	v := &trackingVCS{branch: "mybranch", args: make(map[string]string)}
+	r := &gps.Repo{Root: "example.com/repo", VCS: v}
+	r.Local.RemoteURL, r.Local.Revision, r.Local.Branch = "https://example.com/repo", "local", "mybranch"
+	r.Remote.RepoURL, r.Remote.Branch, r.Remote.Revision, r.Remote.Tracked = "https://example.com/repo", "release", "remote", true
+
+	if ok, reason := new(Pipeline).shouldPresentUpdate(r); !ok {
+		t.Fatalf("got update not presented (%q), want it presented", reason)
+	}
+	// Local branch operations use the local branch, remote ones the upstream branch.
+	if want := map[string]string{"Contains": "mybranch", "RemoteContains": "release"}; !reflect.DeepEqual(v.args, want) {
+		t.Errorf("got branch arguments %v, want %v", v.args, want)
+	}
+}
+
+// trackingVCS is a git repository whose checked out local branch tracks an upstream branch
+// that the remote revision is on. It records the branch arguments it's called with.
+type trackingVCS struct {
+	vcsstate.VCS
+	branch string
+	args   map[string]string // Method name -> branch argument.
+}
+
+func (v *trackingVCS) Branch(string) (string, error) { return v.branch, nil }
+func (v *trackingVCS) Status(string) (string, error) { return "", nil }
+func (v *trackingVCS) Contains(_, _, branch string) (bool, error) {
+	v.args["Contains"] = branch
+	return false, nil
+}
+func (v *trackingVCS) RemoteContains(_, _, branch string) (bool, error) {
+	v.args["RemoteContains"] = branch
+	return true, nil
+}
+
+func TestForkUpstream(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git binary is not available:", err)
+	}
+
+	// Set up an upstream repository, a fork of it that's 2 commits behind,
+	// and a local clone of the fork.
+	upstream, fork, local := t.TempDir(), t.TempDir(), t.TempDir()
+	git(t, upstream, "init", "--quiet")
+	git(t, upstream, "commit", "--quiet", "--allow-empty", "--message", "first")
+	git(t, fork, "clone", "--quiet", upstream, ".")
+	git(t, fork, "commit", "--quiet", "--allow-empty", "--message", "fork only")
+	git(t, upstream, "commit", "--quiet", "--allow-empty", "--message", "second")
+	git(t, upstream, "commit", "--quiet", "--allow-empty", "--message", "third")
+	git(t, local, "clone", "--quiet", fork, ".")
+
+	r := &gps.Repo{Root: "example.com/repo", Path: local, Cmd: vcs.ByCmd("git")}
+	r.Remote.RepoURL = upstream
+	r.Remote.Branch = git(t, fork, "rev-parse", "--abbrev-ref", "HEAD")
+	r.Remote.Revision = git(t, fork, "rev-parse", "HEAD")
+	want := &gps.Upstream{
+		Branch:   git(t, upstream, "rev-parse", "--abbrev-ref", "HEAD"),
+		Revision: git(t, upstream, "rev-parse", "HEAD"),
+		Behind:   2,
+	}
+
+	// Upstream commits aren't available locally, and they're not fetched by default.
+	p := new(Pipeline)
+	if got, err := p.forkUpstream(r); err == nil {
+		t.Errorf("got %+v, want error", got)
+	}
+
+	// The fork comparer is used instead.
+	var compared []string
+	p.CompareForksWith(func(root, upstreamURL, revision, upstreamRevision string) (int, bool, error) {
+		compared = []string{root, upstreamURL, revision, upstreamRevision}
+		return 2, true, nil
+	})
+	got, err := p.forkUpstream(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got != *want {
+		t.Errorf("got %+v, want %+v", *got, *want)
+	}
+	if wantCompared := []string{r.Root, upstream, r.Remote.Revision, want.Revision}; !reflect.DeepEqual(compared, wantCompared) {
+		t.Errorf("got compared %q, want %q", compared, wantCompared)
+	}
+	if hasCommits(local, want.Revision) {
+		t.Error("upstream commits were fetched into the local repository")
+	}
+
+	// Upstream commits are fetched if the fork comparer can't compare, and fetching is enabled.
+	p.CompareForksWith(func(string, string, string, string) (int, bool, error) { return 0, false, nil })
+	p.FetchForks()
+	got, err = p.forkUpstream(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got != *want {
+		t.Errorf("got %+v, want %+v", *got, *want)
+	}
+
+	// An up to date fork presents upstream changes that the fork doesn't have.
+	r.Local.Revision, r.Remote.Upstream = r.Remote.Revision, got
+	if pr := p.presenterRepo(&RepoPresentation{Repo: r}); pr.LocalRevision != r.Remote.Revision || pr.RemoteRevision != want.Revision {
+		t.Errorf("got presented changes %v..%v, want %v..%v", pr.LocalRevision, pr.RemoteRevision, r.Remote.Revision, want.Revision)
+	}
+
+	// A clone of an unrelated repository isn't a fork.
+	unrelated := t.TempDir()
+	git(t, unrelated, "init", "--quiet")
+	git(t, unrelated, "commit", "--quiet", "--allow-empty", "--message", "unrelated")
+	r.Remote.RepoURL = unrelated
+	r.Remote.Branch = git(t, unrelated, "rev-parse", "--abbrev-ref", "HEAD")
+	if got, err := p.forkUpstream(r); err != nil || got != nil {
+		t.Errorf("got %+v, %v for unrelated repository, want nil, nil", got, err)
+	}
+}
+
+func TestLastKnownState(t *testing.T) {
+	fetched := time.Date(2020, 1, 2, 15, 4, 0, 0, time.UTC)
+	store := &memStore{states: map[string]KnownState{
+		"example.com/known": {
+			Branch:        "master",
+			Revision:      "remote",
+			RepoURL:       "https://example.com/known",
+			Presentation:  &presenter.Presentation{Changes: []presenter.Change{{Message: "remembered"}}},
+			LocalRevision: "local",
+			Time:          fetched,
+		},
+	}}
+	remote := remoteVCS{
+		"https://example.com/fresh": "fresh",
+		// Remotes of other repos can't be reached.
+	}
+
+	p := NewPipeline("")
+	p.ResolveRepoRootsWith(func(importPath string) (*vcs.RepoRoot, error) {
+		return &vcs.RepoRoot{VCS: vcs.ByCmd("git"), Repo: "https://" + importPath, Root: importPath}, nil
+	})
+	p.RememberStateIn(store)
+	p.RegisterPresenter(func(context.Context, presenter.Repo) *presenter.Presentation {
+		return &presenter.Presentation{Changes: []presenter.Change{{Message: "presented"}}}
+	})
+	for _, root := range []string{"example.com/known", "example.com/unknown", "example.com/fresh"} {
+		p.AddSubrepo(Subrepo{Root: root, RemoteVCS: remote, RemoteURL: "https://" + root, Revision: "local"})
+	}
+	p.Done()
+	got := make(map[string]*RepoPresentation)
+	for rp := range p.RepoPresentations() {
+		got[rp.Repo.Root] = rp
+	}
+
+	if len(got) != 2 {
+		t.Errorf("got %d presented repos, want 2", len(got))
+	}
+	if rp := got["example.com/known"]; rp == nil || !rp.Repo.Remote.LastKnown.Equal(fetched) ||
+		len(rp.Presentation.Changes) != 1 || rp.Presentation.Changes[0].Message != "remembered" {
+		t.Errorf("got %+v, want last-known state and presentation", rp)
+	}
+	if rp := got["example.com/fresh"]; rp == nil || !rp.Repo.Remote.LastKnown.IsZero() {
+		t.Errorf("got %+v, want current state", rp)
+	}
+	s, ok := store.LastKnown("example.com/fresh")
+	if !ok || s.Revision != "fresh" || s.LocalRevision != "local" || s.Presentation == nil || s.Presentation.Changes[0].Message != "presented" {
+		t.Errorf("got remembered state %+v, want fresh state and presentation", s)
+	}
+}
+
+// memStore is a StateStore in memory.
+type memStore struct {
+	mu     sync.Mutex
+	states map[string]KnownState
+}
+
+func (m *memStore) LastKnown(root string) (KnownState, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.states[root]
+	return s, ok
+}
+
+func (m *memStore) Remember(root string, s KnownState) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.states[root] = s
+}
+
+// remoteVCS is a vcsstate.RemoteVCS with remote revisions of master branches,
+// keyed by remote URL. Other remotes can't be reached.
+type remoteVCS map[string]string
+
+func (r remoteVCS) RemoteBranchAndRevision(remoteURL string) (branch, revision string, err error) {
+	revision, ok := r[remoteURL]
+	if !ok {
+		return "", "", errors.New("network is unreachable")
+	}
+	return "master", revision, nil
+}
+
+// branchVCS is a vcsstate.VCS with branch checked out.
+// Only its Branch method is implemented.
+type branchVCS struct {
+	vcsstate.VCS
+	branch string
+}
+
+func (b branchVCS) Branch(string) (string, error) { return b.branch, nil }
+
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}